@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -10,7 +12,22 @@ import (
 
 // main launches the Bubble Tea program with the Sudoku model.
 func main() {
-	m := sudoku.NewModel()
+	puzzleID := flag.String("puzzle-id", "", "start on the puzzle with this ID (shown in the game header)")
+	flag.Parse()
+
+	var (
+		m   tea.Model
+		err error
+	)
+	if *puzzleID != "" {
+		m, err = sudoku.NewModelFromID(*puzzleID)
+	} else {
+		m, err = sudoku.NewModel()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Println("error:", err)
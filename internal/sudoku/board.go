@@ -1,36 +1,72 @@
 package sudoku
 
 import (
-	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
 )
 
 // boardView renders the full Sudoku board as a string.
 func (m model) boardView() string {
+	blockRows, blockCols := m.set.blocks()
+	d := m.decor()
 	var lines []string
 	for row := 0; row < m.set.size; row++ {
-		lines = append(lines, m.rowView(row)...)
+		lines = append(lines, m.rowView(row, d)...)
 		if row == m.set.size-1 {
 			continue
 		}
 		gapLines := rowGapLines
-		if (row+1)%m.set.boxRows == 0 {
+		if (row+1)%blockRows == 0 {
 			gapLines = boxGapLines
 		}
-		lines = append(lines, blankLines(gapLines, boardWidth(m.set.size, m.set.boxCols))...)
+		if gapLines > 0 && kropkiOf(m.puzzle.rules) != nil {
+			lines = append(lines, m.dotGapLine(row))
+			gapLines--
+		}
+		lines = append(lines, blankLines(gapLines, boardWidth(m.set.size, blockCols))...)
 	}
 	return strings.Join(lines, "\n")
 }
 
-// rowView renders a single logical row (cellH lines).
-func (m model) rowView(row int) []string {
+// boardDecor is what a variant draws over every cell, worked out once
+// per render: the shape of each outlined cell and how to draw the
+// outlines, how the thermometers or arrows pass through each cell on
+// one, and which cells lie in a Windoku window.
+type boardDecor struct {
+	shapes  []int
+	style   *outlineStyle
+	paths   map[int]pathCell
+	windows []bool
+}
+
+// decor works out the board's decorations for one render.
+func (m model) decor() boardDecor {
+	d := boardDecor{paths: m.pathCells()}
+	d.shapes, d.style = m.outline()
+	if m.set.variant == "windoku" {
+		d.windows = make([]bool, m.set.size*m.set.size)
+		for _, window := range engine.Windows(m.set.geometry()) {
+			for _, i := range window.Cells {
+				d.windows[i] = true
+			}
+		}
+	}
+	return d
+}
+
+// rowView renders a single logical row (cellHeight lines).
+func (m model) rowView(row int, d boardDecor) []string {
+	cellH := cellHeight(m.set.size)
+	_, blockCols := m.set.blocks()
 	lines := make([]string, cellH)
 	for col := 0; col < m.set.size; col++ {
 		gap := ""
 		if col > 0 {
-			if col%m.set.boxCols == 0 {
+			if col%blockCols == 0 {
 				gap = boxGap
 			} else {
 				gap = cellGap
@@ -38,10 +74,14 @@ func (m model) rowView(row int) []string {
 		}
 
 		value := m.grid[idx(row, col, m.set.size)]
-		cell := m.renderCellLines(row, col, value)
+		cell := m.renderCellLines(row, col, value, d)
 		for i := 0; i < cellH; i++ {
 			if gap != "" {
-				lines[i] += gap
+				if mark := m.dotMark(row, col-1, row, col); mark != "" && i == cellH/2 {
+					lines[i] += mark + gap[1:]
+				} else {
+					lines[i] += gap
+				}
 			}
 			lines[i] += cell[i]
 		}
@@ -49,22 +89,30 @@ func (m model) rowView(row int) []string {
 	return lines
 }
 
-// renderCellLines renders a single cell as cellH lines.
-func (m model) renderCellLines(row, col int, value uint8) []string {
+// renderCellLines renders a single cell as cellHeight lines.
+func (m model) renderCellLines(row, col int, value uint8, d boardDecor) []string {
+	cellW, cellH := cellWidth(m.set.size), cellHeight(m.set.size)
 	selected := m.row == row && m.col == col
 	fixed := m.isFixed(row, col)
 	conflict := m.showConflicts && m.hasConflict(row, col)
-	inBoxRow := row % m.set.boxRows
-	inBoxCol := col % m.set.boxCols
+	blockRows, blockCols := m.set.blocks()
+	inBoxRow := row % blockRows
+	inBoxCol := col % blockCols
 	checker := (inBoxRow+inBoxCol)%2 == 0
 	selectedValue := m.grid[idx(m.row, m.col, m.set.size)]
-	isPeer := row == m.row || col == m.col
+	isPeer := row == m.row || col == m.col || m.sameGroup(row, col)
 	sameNumber := selectedValue != 0 && value == selectedValue && !selected
 
 	bg := bgBase1
 	if !checker {
 		bg = bgBase2
 	}
+	if shade1, shade2, ok := m.shade(row, col, d); ok {
+		bg = shade1
+		if !checker {
+			bg = shade2
+		}
+	}
 	if isPeer && !selected {
 		if checker {
 			bg = bgPeer1
@@ -99,54 +147,466 @@ func (m model) renderCellLines(row, col int, value uint8) []string {
 		bold = true
 	}
 
+	frame := m.cellFrame(row, col, d)
+	innerW, innerH := cellW-frame.leftWidth(), cellH-frame.topHeight()
 	cellStyle := lipgloss.NewStyle().
-		Width(cellW).
+		Width(innerW).
 		Foreground(fg).
 		Background(bg)
 
 	if bold {
 		cellStyle = cellStyle.Bold(true)
 	}
+	edgeStyle := lipgloss.NewStyle().Background(bg).Foreground(fgDot)
+	if frame.style != nil {
+		edgeStyle = edgeStyle.Foreground(frame.style.color)
+	}
+	sumStyle := edgeStyle.Foreground(fgCageSum).Bold(true)
 
-	lines := make([]string, cellH)
-	for i := 0; i < cellH; i++ {
-		lines[i] = cellStyle.Render(strings.Repeat(" ", cellW))
+	lines := make([]string, innerH)
+	for i := 0; i < innerH; i++ {
+		lines[i] = cellStyle.Render(strings.Repeat(" ", innerW))
 	}
 
+	rowMid := (cellH / 2) - frame.topHeight()
+	colMid := (cellW / 2) - frame.leftWidth()
+	textStyle := cellStyle.UnsetWidth()
+	pathStyle := textStyle.Foreground(fgPath).Bold(false)
+	index := idx(row, col, m.set.size)
+	path, onPath := d.paths[index]
 	if value == 0 {
-		index := idx(row, col, m.set.size)
 		if index < len(m.notes) && m.notes[index] != 0 {
 			noteStyle := cellStyle.Foreground(fgNote)
-			return renderNotes(m.notes[index], m.set.size, noteStyle)
+			lines = renderNotes(m.notes[index], m.set.size, m.glyphs, noteStyle, innerW, innerH)
+		} else if onPath {
+			lines = path.lines("", textStyle, pathStyle, innerW, innerH, rowMid, colMid)
 		}
-		return lines
+		return frame.wrap(lines, edgeStyle, sumStyle, cellW)
 	}
 
-	label := fmt.Sprintf("%d", value)
-	rowMid := (cellH / 2)
-	colMid := (cellW / 2)
+	label := m.glyphs.label(int(value))
+	if onPath {
+		lines = path.lines(label, textStyle, pathStyle, innerW, innerH, rowMid, colMid)
+		return frame.wrap(lines, edgeStyle, sumStyle, cellW)
+	}
 	start := colMid - (len(label) / 2)
 	if start < 0 {
 		start = 0
 	}
-	if start+len(label) > cellW {
-		start = cellW - len(label)
+	if start+len(label) > innerW {
+		start = innerW - len(label)
 	}
 
-	content := strings.Repeat(" ", start) + label + strings.Repeat(" ", cellW-start-len(label))
+	content := strings.Repeat(" ", start) + label + strings.Repeat(" ", innerW-start-len(label))
 	lines[rowMid] = cellStyle.Render(content)
+	return frame.wrap(lines, edgeStyle, sumStyle, cellW)
+}
+
+// shade returns the checkered backgrounds of a cell in a shaded variant
+// region, an X-Sudoku diagonal, a Windoku window, a thermometer or an
+// arrow, and reports whether the cell is in one. Thermometer bulbs and
+// arrow circles are shaded apart from the rest of the path.
+func (m model) shade(row, col int, d boardDecor) (lipgloss.Color, lipgloss.Color, bool) {
+	index := idx(row, col, m.set.size)
+	switch m.set.variant {
+	case "thermo", "arrow":
+		path, ok := d.paths[index]
+		if path.bulb || path.circle {
+			return bgBulb1, bgBulb2, true
+		}
+		return bgPath1, bgPath2, ok
+	case "x":
+		return bgDiagonal1, bgDiagonal2, row == col || row+col == m.set.size-1
+	case "windoku":
+		return bgWindow1, bgWindow2, d.windows[index]
+	}
+	return "", "", false
+}
+
+// sameGroup reports whether a cell of a Disjoint Groups board sits at the
+// same spot in its box as the selected cell, so the group is highlighted
+// with the selected row and column.
+func (m model) sameGroup(row, col int) bool {
+	return m.set.variant == "disjoint" &&
+		row%m.set.boxRows == m.row%m.set.boxRows &&
+		col%m.set.boxCols == m.col%m.set.boxCols
+}
+
+// pathCell is how the thermometers or arrows pass through a cell: the
+// arms of the path toward the cells before and after it, as outline
+// arms, whether the cell is a bulb or an arrow's circle, and, at an
+// arrow's tip, the arm its head points along.
+type pathCell struct {
+	arms   int
+	bulb   bool
+	circle bool
+	head   int
+}
+
+// pathJunctions draws an empty cell on a path, indexed by the path's
+// arms like outlineStyle.junctions.
+var pathJunctions = [16]string{" ", "╵", "╶", "└", "╷", "│", "┌", "├", "╴", "┘", "─", "┴", "┐", "┤", "┬", "┼"}
+
+// thermoBulb marks an empty bulb.
+const thermoBulb = "●"
+
+// arrowCircle marks an empty arrow circle.
+const arrowCircle = "○"
+
+// arrowHeads draws an arrowhead, indexed by the arm it points along.
+var arrowHeads = map[int]string{outlineUp: "▴", outlineRight: "▸", outlineDown: "▾", outlineLeft: "◂"}
+
+// pathCells returns how the thermometers or arrows pass through each
+// cell on one, by cell index.
+func (m model) pathCells() map[int]pathCell {
+	size := m.set.size
+	cells := map[int]pathCell{}
+	for _, path := range m.paths() {
+		for p, index := range path {
+			cell := cells[index]
+			if p > 0 {
+				cell.arms |= armToward(index, path[p-1], size)
+			}
+			if p < len(path)-1 {
+				cell.arms |= armToward(index, path[p+1], size)
+			}
+			if m.set.variant == "arrow" {
+				cell.circle = cell.circle || p == 0
+				if p == len(path)-1 {
+					cell.head = armToward(path[p-1], index, size)
+				}
+			} else {
+				cell.bulb = cell.bulb || p == 0
+			}
+			cells[index] = cell
+		}
+	}
+	return cells
+}
+
+// paths lists the cells of each thermometer from its bulb, or of each
+// arrow from its circle to its tip.
+func (m model) paths() [][]int {
+	if t := thermosOf(m.puzzle.rules); t != nil {
+		return t.Paths()
+	}
+	a := arrowsOf(m.puzzle.rules)
+	if a == nil {
+		return nil
+	}
+	paths := make([][]int, len(a.Arrows()))
+	for i, arrow := range a.Arrows() {
+		paths[i] = append([]int{arrow.Circle}, arrow.Cells...)
+	}
+	return paths
+}
+
+// armToward returns the outline arm pointing from a cell to its
+// orthogonal neighbour.
+func armToward(from, to, size int) int {
+	switch to - from {
+	case -size:
+		return outlineUp
+	case size:
+		return outlineDown
+	case -1:
+		return outlineLeft
+	default:
+		return outlineRight
+	}
+}
+
+// lines draws a path cell: the path runs from the middle to each edge
+// it leaves through, around the cell's label or, with none, through the
+// middle, where a dot marks an empty bulb and a ring an empty circle. A
+// filled circle rings its label in parentheses, and an arrow's head sits
+// at the edge it points to, or in the middle of an empty tip.
+func (c pathCell) lines(label string, text, path lipgloss.Style, width, height, mid, center int) []string {
+	glyph, gap := label, 1
+	switch {
+	case label == "":
+		glyph, gap, text = pathJunctions[c.arms], 0, path
+		switch {
+		case c.bulb:
+			glyph = thermoBulb
+		case c.circle:
+			glyph = arrowCircle
+		case c.head != 0:
+			glyph = arrowHeads[c.head]
+		}
+	case c.circle && lipgloss.Width(label)+2 < width:
+		glyph = "(" + label + ")"
+	}
+	head := ""
+	if label != "" {
+		head = arrowHeads[c.head]
+	}
+	n := lipgloss.Width(glyph)
+	start := min(max(center-n/2, 0), width-n)
+	lines := make([]string, height)
+	for i := range lines {
+		switch {
+		case i == mid:
+			left, right := strings.Repeat(" ", start), strings.Repeat(" ", width-start-n)
+			if c.arms&outlineLeft != 0 && start > gap {
+				left = strings.Repeat("─", start-gap) + strings.Repeat(" ", gap)
+			}
+			if c.arms&outlineRight != 0 && len(right) > gap {
+				right = strings.Repeat(" ", gap) + strings.Repeat("─", len(right)-gap)
+			}
+			// The head points away from the tip's only arm, so its side
+			// holds just spaces.
+			if head != "" && c.head == outlineLeft && start > 0 {
+				left = head + left[1:]
+			}
+			if head != "" && c.head == outlineRight && len(right) > 0 {
+				right = right[1:] + head
+			}
+			lines[i] = path.Render(left) + text.Render(glyph) + path.Render(right)
+		case head != "" && (i == 0 && c.head == outlineUp || i == height-1 && c.head == outlineDown):
+			lines[i] = path.Render(strings.Repeat(" ", center) + head + strings.Repeat(" ", width-center-1))
+		case i < mid && c.arms&outlineUp != 0, i > mid && c.arms&outlineDown != 0:
+			lines[i] = path.Render(strings.Repeat(" ", center) + "│" + strings.Repeat(" ", width-center-1))
+		default:
+			lines[i] = path.Render(strings.Repeat(" ", width))
+		}
+	}
+	return lines
+}
+
+// outlineStyle draws one kind of outline. Its junctions are indexed by
+// the arms meeting at a corner, outlineUp | outlineRight | outlineDown |
+// outlineLeft, so the straight runs are junctions[outlineRight|outlineLeft]
+// and junctions[outlineUp|outlineDown].
+type outlineStyle struct {
+	junctions [16]string
+	color     lipgloss.Color
+}
+
+// The arms of an outline junction.
+const (
+	outlineUp = 1 << iota
+	outlineRight
+	outlineDown
+	outlineLeft
+)
+
+// cageOutline draws Killer cages with light dashes; boxOutline draws
+// Jigsaw boxes with heavy lines.
+var (
+	cageOutline = outlineStyle{
+		junctions: [16]string{" ", "┆", "┄", "└", "┆", "┆", "┌", "├", "┄", "┘", "┄", "┴", "┐", "┤", "┬", "┼"},
+		color:     fgCage,
+	}
+	boxOutline = outlineStyle{
+		junctions: [16]string{" ", "╹", "╺", "┗", "╻", "┃", "┏", "┣", "╸", "┛", "━", "┻", "┓", "┫", "┳", "╋"},
+		color:     fgBox,
+	}
+)
+
+// cellFrame is the part of the outlines drawn inside one cell: a line
+// along the top when the cell above is in another shape, one down the
+// left when the cell to the left is, the junction where outlines meet at
+// the cell's top-left corner, and a Killer cage's sum in its first cell.
+// Outlines are only drawn between cells, so the board border closes
+// shapes at the edge. Kropki boards draw no outlines; instead the dots
+// on a cell's top and left edges inside its box sit in the middle of
+// those edges.
+type cellFrame struct {
+	top     bool
+	left    bool
+	arms    int
+	label   string
+	style   *outlineStyle
+	dotTop  string
+	dotLeft string
+}
+
+// outline returns the shape of every cell the board outlines and how to
+// draw them: the cages of Killer puzzles or the boxes of Jigsaw ones.
+// Other puzzles have none.
+func (m model) outline() ([]int, *outlineStyle) {
+	if k := killerOf(m.puzzle.rules); k != nil {
+		shapes := make([]int, m.set.size*m.set.size)
+		for i := range shapes {
+			shapes[i] = k.CageOf(i)
+		}
+		return shapes, &cageOutline
+	}
+	if m.set.variant == "jigsaw" && m.puzzle.rules != nil {
+		return m.puzzle.rules.BoxMap(), &boxOutline
+	}
+	return nil, nil
+}
+
+// cellFrame returns the outlines drawn inside a cell.
+func (m model) cellFrame(row, col int, d boardDecor) cellFrame {
+	shapes := d.shapes
+	if shapes == nil {
+		blockRows, blockCols := m.set.blocks()
+		frame := cellFrame{}
+		if row%blockRows != 0 {
+			frame.dotTop = m.dotMark(row-1, col, row, col)
+		}
+		if col%blockCols != 0 {
+			frame.dotLeft = m.dotMark(row, col-1, row, col)
+		}
+		return frame
+	}
+	size := m.set.size
+	split := func(r1, c1, r2, c2 int) bool {
+		return r1 >= 0 && c1 >= 0 && shapes[idx(r1, c1, size)] != shapes[idx(r2, c2, size)]
+	}
+	frame := cellFrame{
+		top:   split(row-1, col, row, col),
+		left:  split(row, col-1, row, col),
+		style: d.style,
+	}
+	if split(row-1, col-1, row-1, col) {
+		frame.arms |= outlineUp
+	}
+	if frame.top {
+		frame.arms |= outlineRight
+		if col == 0 {
+			frame.arms |= outlineLeft
+		}
+	}
+	if frame.left {
+		frame.arms |= outlineDown
+	}
+	if split(row-1, col-1, row, col-1) {
+		frame.arms |= outlineLeft
+	}
+	index := idx(row, col, size)
+	if k := killerOf(m.puzzle.rules); k != nil {
+		if c := k.CageOf(index); c >= 0 && slices.Min(k.Cages()[c].Cells) == index {
+			frame.label = strconv.Itoa(k.Cages()[c].Sum)
+		}
+	}
+	return frame
+}
+
+// topHeight returns the lines the frame takes at the top of a cell: one
+// when an outline runs along or into the top edge, or for a cage sum.
+func (f cellFrame) topHeight() int {
+	if f.arms&(outlineRight|outlineLeft) != 0 || f.label != "" || f.dotTop != "" {
+		return 1
+	}
+	return 0
+}
+
+// leftWidth returns the columns the frame takes at the left of a cell.
+func (f cellFrame) leftWidth() int {
+	if f.left || f.dotLeft != "" {
+		return 1
+	}
+	return 0
+}
+
+// wrap draws the frame around a cell's rendered inner lines.
+func (f cellFrame) wrap(inner []string, edge, sum lipgloss.Style, width int) []string {
+	lines := make([]string, 0, len(inner)+1)
+	if f.dotTop != "" {
+		lines = append(lines, edge.Render(markAt(f.dotTop, width/2, width)))
+	} else if f.topHeight() > 0 {
+		fill := " "
+		if f.top {
+			fill = f.style.junctions[outlineRight|outlineLeft]
+		}
+		label := f.label
+		if len(label) > width-1 {
+			label = label[:width-1]
+		}
+		lines = append(lines, edge.Render(f.style.junctions[f.arms])+sum.Render(label)+edge.Render(strings.Repeat(fill, width-1-len(label))))
+	}
+	mid := (len(inner)+f.topHeight())/2 - f.topHeight()
+	for i, line := range inner {
+		switch {
+		case f.left:
+			line = edge.Render(f.style.junctions[outlineUp|outlineDown]) + line
+		case f.dotLeft != "":
+			mark := " "
+			if i == mid {
+				mark = f.dotLeft
+			}
+			line = edge.Render(mark) + line
+		}
+		lines = append(lines, line)
+	}
 	return lines
 }
 
-// renderNotes prints candidate notes inside a cell.
-func renderNotes(notes uint16, size int, style lipgloss.Style) []string {
+// dotGlyphs draws Kropki dots by kind.
+var dotGlyphs = map[engine.DotKind]string{
+	engine.WhiteDot: "○",
+	engine.BlackDot: "●",
+}
+
+// dotMark returns the glyph of the Kropki dot between two cells, or ""
+// when there is none.
+func (m model) dotMark(r1, c1, r2, c2 int) string {
+	k := kropkiOf(m.puzzle.rules)
+	if k == nil || r1 < 0 || c1 < 0 {
+		return ""
+	}
+	dot, ok := k.DotBetween(idx(r1, c1, m.set.size), idx(r2, c2, m.set.size))
+	if !ok {
+		return ""
+	}
+	return dotGlyphs[dot.Kind]
+}
+
+// dotGapLine renders the gap line below a row of boxes with the dots
+// joining the cells on either side under the middle of each cell.
+func (m model) dotGapLine(row int) string {
+	_, blockCols := m.set.blocks()
+	cellW := cellWidth(m.set.size)
+	dotStyle := lipgloss.NewStyle().Foreground(fgDot)
+	var b strings.Builder
+	for col := 0; col < m.set.size; col++ {
+		if col > 0 && col%blockCols == 0 {
+			b.WriteString(boxGap)
+		} else if col > 0 {
+			b.WriteString(cellGap)
+		}
+		mark := m.dotMark(row, col, row+1, col)
+		if mark == "" {
+			b.WriteString(strings.Repeat(" ", cellW))
+			continue
+		}
+		b.WriteString(strings.Repeat(" ", cellW/2) + dotStyle.Render(mark) + strings.Repeat(" ", cellW-cellW/2-1))
+	}
+	return b.String()
+}
+
+// markAt returns a line of spaces of a width with a mark at a column.
+func markAt(mark string, at, width int) string {
+	return strings.Repeat(" ", at) + mark + strings.Repeat(" ", width-at-1)
+}
+
+// renderNotes prints candidate notes in a cell area of a given width and
+// height. Single-character glyphs on boards beyond 9x9 are packed without
+// spaces.
+func renderNotes(notes uint16, size int, glyphs glyphSet, style lipgloss.Style, cellW, cellH int) []string {
 	tokens := make([]string, 0, size)
 	for i := 1; i <= size; i++ {
 		if notes&(1<<uint(i-1)) != 0 {
-			tokens = append(tokens, fmt.Sprintf("%d", i))
+			tokens = append(tokens, glyphs.label(i))
+		}
+	}
+	sep := " "
+	if size > 9 && glyphs.compact(size) {
+		sep = ""
+	}
+	wrapped := wrapTokens(tokens, sep, cellW)
+	if len(wrapped) > cellH {
+		wrapped = wrapped[:cellH]
+		last := wrapped[cellH-1]
+		if len(last)+len(sep)+1 > cellW {
+			last = dropLastToken(last, sep)
 		}
+		wrapped[cellH-1] = last + sep + "+"
 	}
-	wrapped := wrapTokens(tokens, cellW)
 	lines := make([]string, cellH)
 	for i := 0; i < cellH; i++ {
 		line := ""
@@ -158,8 +618,8 @@ func renderNotes(notes uint16, size int, style lipgloss.Style) []string {
 	return lines
 }
 
-// wrapTokens wraps tokens into lines of a fixed width.
-func wrapTokens(tokens []string, width int) []string {
+// wrapTokens joins tokens with sep into lines of a fixed width.
+func wrapTokens(tokens []string, sep string, width int) []string {
 	if len(tokens) == 0 {
 		return nil
 	}
@@ -170,8 +630,8 @@ func wrapTokens(tokens []string, width int) []string {
 			current = token
 			continue
 		}
-		if len(current)+1+len(token) <= width {
-			current += " " + token
+		if len(current)+len(sep)+len(token) <= width {
+			current += sep + token
 		} else {
 			lines = append(lines, current)
 			current = token
@@ -183,6 +643,18 @@ func wrapTokens(tokens []string, width int) []string {
 	return lines
 }
 
+// dropLastToken removes the final token from a wrapped line, making room
+// for the overflow marker.
+func dropLastToken(line, sep string) string {
+	if sep == "" {
+		return line[:len(line)-1]
+	}
+	if cut := strings.LastIndex(line, sep); cut > 0 {
+		return line[:cut]
+	}
+	return line
+}
+
 // padRight pads a string with spaces to a fixed width.
 func padRight(value string, width int) string {
 	if len(value) >= width {
@@ -195,7 +667,29 @@ func padRight(value string, width int) string {
 func boardWidth(size, boxCols int) int {
 	gaps := (size - 1) * cellGapW
 	boxExtra := (size/boxCols - 1) * (boxGapW - cellGapW)
-	return size*cellW + gaps + boxExtra
+	return size*cellWidth(size) + gaps + boxExtra
+}
+
+// cellWidth returns the character width of a cell; boards beyond 9x9 use
+// narrower cells so they fit a terminal.
+func cellWidth(size int) int {
+	switch {
+	case size <= 9:
+		return 9
+	case size <= 12:
+		return 7
+	default:
+		return 6
+	}
+}
+
+// cellHeight returns the line height of a cell; 16x16 boards use two-line
+// cells.
+func cellHeight(size int) int {
+	if size > 12 {
+		return 2
+	}
+	return 3
 }
 
 // boardFrameWidth includes the outer border width.
@@ -2,8 +2,6 @@ package sudoku
 
 // Layout and persistence constants.
 const (
-	cellW     = 9
-	cellH     = 3
 	boardPadX = 2
 	boardPadY = 1
 	headerPad = 1
@@ -13,6 +11,7 @@ const (
 	savesFile   = ".sudoku_saves.json"
 	statsFile   = ".sudoku_stats.json"
 	puzzlesFile = "puzzles.json"
+	poolFile    = ".sudoku_pool.json"
 )
 
 const (
@@ -1,28 +1,84 @@
 package sudoku
 
 import (
+	"slices"
+	"strings"
 	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
 )
 
-// setSize switches the puzzle size and resets the game.
-func (m *model) setSize(size int) {
+// setSize switches the puzzle size and resets the game, dropping back to
+// classic rules when the variant is not played on the new size.
+func (m *model) setSize(size int) tea.Cmd {
 	set, ok := puzzleSets[size]
 	if !ok {
-		return
+		return nil
 	}
-	m.set = set
-	m.newPuzzle()
+	if set = set.withVariant(m.set.variant); !set.fits() {
+		set = set.withVariant(variantClassic)
+	}
+	return m.startPuzzle(set, m.requested)
 }
 
-// setDifficulty switches difficulty and resets the game.
-func (m *model) setDifficulty(diff difficulty) {
-	m.difficulty = diff
-	m.newPuzzle()
+// setVariant switches the variant and starts a new puzzle.
+func (m *model) setVariant(name string) tea.Cmd {
+	return m.startPuzzle(m.set.withVariant(name), m.requested)
 }
 
-// newPuzzle generates and loads a new puzzle.
-func (m *model) newPuzzle() {
-	m.setPuzzle(generatePuzzle(m.set, m.difficulty))
+// setDifficulty switches difficulty and resets the game.
+func (m *model) setDifficulty(diff difficulty) tea.Cmd {
+	return m.startPuzzle(m.set, diff)
+}
+
+// setSymmetry switches the clue layout symmetry and starts a new puzzle.
+func (m *model) setSymmetry(sym engine.Symmetry) tea.Cmd {
+	m.symmetry = sym
+	return m.startPuzzle(m.set, m.requested)
+}
+
+// newPuzzle starts a new puzzle at the current size and difficulty.
+func (m *model) newPuzzle() tea.Cmd {
+	return m.startPuzzle(m.set, m.requested)
+}
+
+// startPuzzle loads a ready puzzle for a size/difficulty when one exists
+// and otherwise generates one in the background, at the tier the board
+// offers for diff (see puzzleSet.tier). The current game stays
+// playable until the generated puzzle arrives.
+func (m *model) startPuzzle(set puzzleSet, diff difficulty) tea.Cmd {
+	diff = set.tier(diff)
+	if p, ok := m.readyPuzzle(set, diff); ok {
+		m.generating = false
+		m.loadPuzzle(set, diff, p, "New puzzle")
+		return m.refill()
+	}
+	m.genID++
+	m.genSet = set
+	m.genDiff = diff
+	cmds := []tea.Cmd{generateCmd(m.session.generator(set.variant), m.genID, set, diff, m.symmetry)}
+	if !m.generating {
+		m.generating = true
+		cmds = append(cmds, spinnerCmd())
+	}
+	return tea.Batch(cmds...)
+}
+
+// loadPuzzle replaces the game with a new puzzle and resets counters. The
+// game takes the puzzle's rated difficulty, while diff records what the
+// player asked for. A puzzle without rules for the set leaves the current
+// game in place and says why.
+func (m *model) loadPuzzle(set puzzleSet, diff difficulty, p puzzle, message string) {
+	grid, state, err := newState(set, p, copyGrid(p.puzzle))
+	if err != nil {
+		m.flash("Cannot load puzzle: " + err.Error())
+		return
+	}
+	m.set = set
+	m.difficulty = p.difficulty
+	m.requested = diff
+	m.setPuzzle(p, grid, state)
 	m.notes = make([]uint16, m.set.size*m.set.size)
 	m.mistakes = 0
 	m.hintsUsed = 0
@@ -30,14 +86,17 @@ func (m *model) newPuzzle() {
 	m.elapsedAtSolve = 0
 	m.gameOver = false
 	m.clearHistory()
-	m.flash("New puzzle")
+	m.flash(message)
 	m.autoSave()
 }
 
-// setPuzzle replaces the current puzzle data.
-func (m *model) setPuzzle(p puzzle) {
+// setPuzzle replaces the current puzzle data with a grid and state built
+// by newState.
+func (m *model) setPuzzle(p puzzle, grid []uint8, state *engine.State) {
 	m.puzzle = p
-	m.grid = copyGrid(p.puzzle)
+	m.grid = grid
+	m.state = state
+	m.hintNote = ""
 	m.row = 0
 	m.col = 0
 	m.start = time.Now()
@@ -47,7 +106,8 @@ func (m *model) setPuzzle(p puzzle) {
 
 // reset restores the puzzle to its initial state.
 func (m *model) reset() {
-	m.grid = copyGrid(m.puzzle.puzzle)
+	m.setGrid(copyGrid(m.puzzle.puzzle))
+	m.hintNote = ""
 	m.row = 0
 	m.col = 0
 	m.start = time.Now()
@@ -112,46 +172,54 @@ func equalGrid(a, b []uint8) bool {
 
 // anyConflicts returns true if any cell violates Sudoku rules.
 func (m model) anyConflicts() bool {
-	for row := 0; row < m.set.size; row++ {
-		for col := 0; col < m.set.size; col++ {
-			if m.hasConflict(row, col) {
-				return true
-			}
-		}
-	}
-	return false
+	return m.state.HasConflicts()
 }
 
 // hasConflict checks whether a specific cell violates Sudoku rules.
 func (m model) hasConflict(row, col int) bool {
-	value := m.grid[idx(row, col, m.set.size)]
-	if value == 0 {
-		return false
-	}
-
-	for c := 0; c < m.set.size; c++ {
-		if c != col && m.grid[idx(row, c, m.set.size)] == value {
-			return true
-		}
-	}
+	return m.state.Conflict(idx(row, col, m.set.size))
+}
 
-	for r := 0; r < m.set.size; r++ {
-		if r != row && m.grid[idx(r, col, m.set.size)] == value {
-			return true
+// setCell writes a value to the grid and keeps the candidate state in step.
+func (m *model) setCell(index int, value uint8) {
+	m.grid[index] = value
+	m.state.Set(index, value)
+}
+
+// setGrid replaces the grid and rebuilds the candidate state under the
+// rules of the game in progress. A grid that does not fit the board falls
+// back to the puzzle's givens.
+func (m *model) setGrid(grid []uint8) {
+	rules := m.state.Rules()
+	state, err := rules.NewState(grid)
+	if err != nil {
+		grid = copyGrid(m.puzzle.puzzle)
+		state, _ = rules.NewState(grid)
+	}
+	m.grid = grid
+	m.state = state
+}
+
+// newState builds the grid and candidate state for a puzzle on a set,
+// under the puzzle's own rules or the set's standard rules. A grid that
+// does not fit the board falls back to the puzzle's givens. It fails when
+// the set has no rules or the givens do not fit them either.
+func newState(set puzzleSet, p puzzle, grid []uint8) ([]uint8, *engine.State, error) {
+	rules := p.rules
+	if rules == nil {
+		var err error
+		if rules, err = set.rules(); err != nil {
+			return nil, nil, err
 		}
 	}
-
-	boxRow := (row / m.set.boxRows) * m.set.boxRows
-	boxCol := (col / m.set.boxCols) * m.set.boxCols
-	for r := boxRow; r < boxRow+m.set.boxRows; r++ {
-		for c := boxCol; c < boxCol+m.set.boxCols; c++ {
-			if (r != row || c != col) && m.grid[idx(r, c, m.set.size)] == value {
-				return true
-			}
+	state, err := rules.NewState(grid)
+	if err != nil {
+		grid = copyGrid(p.puzzle)
+		if state, err = rules.NewState(grid); err != nil {
+			return nil, nil, err
 		}
 	}
-
-	return false
+	return grid, state, nil
 }
 
 // setValue writes a value to the current cell and updates state.
@@ -164,7 +232,8 @@ func (m *model) setValue(value uint8) {
 		return
 	}
 	m.pushUndo()
-	m.grid[index] = value
+	m.setCell(index, value)
+	m.hintNote = ""
 	if value != 0 {
 		m.notes[index] = 0
 		m.pruneNotes(m.row, m.col, value)
@@ -194,8 +263,9 @@ func (m *model) clearValue() {
 		return
 	}
 	m.pushUndo()
-	m.grid[index] = 0
+	m.setCell(index, 0)
 	m.notes[index] = 0
+	m.hintNote = ""
 	if !m.gameOver {
 		m.checkSolved()
 	}
@@ -221,6 +291,49 @@ func (m *model) toggleNote(row, col, value int) {
 	m.autoSave()
 }
 
+// enterValue sets a value in the selected cell, or toggles it as a note
+// in notes mode.
+func (m *model) enterValue(value int) {
+	if m.noteMode {
+		m.toggleNote(m.row, m.col, value)
+	} else {
+		m.setValue(uint8(value))
+	}
+}
+
+// typeEntry adds a key to a prefix entry and enters the value once the
+// typed label can only mean one value.
+func (m *model) typeEntry(key string) {
+	input := m.entryInput + key
+	value, complete, valid := m.glyphs.match(input, m.set.size)
+	switch {
+	case !valid:
+		m.cancelEntry()
+		m.flash("No value " + input)
+	case complete:
+		m.cancelEntry()
+		m.enterValue(value)
+	default:
+		m.entryInput = input
+	}
+}
+
+// confirmEntry enters a prefix entry whose label also starts longer ones,
+// such as 1 on a 16x16 board.
+func (m *model) confirmEntry() {
+	value, _, _ := m.glyphs.match(m.entryInput, m.set.size)
+	m.cancelEntry()
+	if value != 0 {
+		m.enterValue(value)
+	}
+}
+
+// cancelEntry leaves prefix entry.
+func (m *model) cancelEntry() {
+	m.entering = false
+	m.entryInput = ""
+}
+
 // clearNotes removes all notes from a cell.
 func (m *model) clearNotes(row, col int) {
 	if m.isFixed(row, col) {
@@ -235,35 +348,23 @@ func (m *model) clearNotes(row, col int) {
 	m.autoSave()
 }
 
-// pruneNotes removes a value from notes in the affected row/col/box.
+// pruneNotes removes a value from notes in every cell that shares a
+// region with the given cell. Rules beyond regions can affect any cell,
+// so then every note is narrowed to what the rules still allow.
 func (m *model) pruneNotes(row, col int, value uint8) {
 	if value == 0 {
 		return
 	}
 	mask := uint16(1 << uint(value-1))
-	for c := 0; c < m.set.size; c++ {
-		if c == col {
-			continue
-		}
-		i := idx(row, c, m.set.size)
+	for _, i := range m.state.Peers(idx(row, col, m.set.size)) {
 		m.notes[i] &^= mask
 	}
-	for r := 0; r < m.set.size; r++ {
-		if r == row {
-			continue
-		}
-		i := idx(r, col, m.set.size)
-		m.notes[i] &^= mask
+	if m.state.Rules().RegionsOnly() {
+		return
 	}
-	boxRow := (row / m.set.boxRows) * m.set.boxRows
-	boxCol := (col / m.set.boxCols) * m.set.boxCols
-	for r := boxRow; r < boxRow+m.set.boxRows; r++ {
-		for c := boxCol; c < boxCol+m.set.boxCols; c++ {
-			if r == row && c == col {
-				continue
-			}
-			i := idx(r, c, m.set.size)
-			m.notes[i] &^= mask
+	for i := range m.notes {
+		if m.notes[i] != 0 {
+			m.notes[i] &= m.state.Candidates(i)
 		}
 	}
 }
@@ -322,7 +423,7 @@ func (m *model) snapshot() snapshot {
 
 // applySnapshot restores a snapshot.
 func (m *model) applySnapshot(snap snapshot) {
-	m.grid = copyGrid(snap.grid)
+	m.setGrid(copyGrid(snap.grid))
 	m.notes = copyNotes(snap.notes)
 	m.row = snap.row
 	m.col = snap.col
@@ -337,16 +438,57 @@ func (m *model) clearHistory() {
 	m.redoStack = nil
 }
 
-// applyHint applies a hint (logic first, then a reveal).
-func (m *model) applyHint() {
-	if m.solved || m.gameOver {
+// hintMsg delivers the placement the technique engine justified for the
+// board a hint was asked on, with the message and explanation to show.
+type hintMsg struct {
+	puzzleID string
+	grid     []uint8
+	place    engine.Candidate
+	message  string
+	note     string
+	ok       bool
+}
+
+// applyHint starts a hint: the technique engine looks for the next
+// logical placement in the background, since chains on large or variant
+// boards can take a while, and a random cell is revealed when it finds
+// none.
+func (m *model) applyHint() tea.Cmd {
+	if m.solved || m.gameOver || m.hinting {
+		return nil
+	}
+	m.hinting = true
+	m.flash("Looking for a hint")
+	return hintCmd(m.state.Rules(), m.set.geometry(), copyGrid(m.grid), m.puzzle.solution, m.puzzle.id)
+}
+
+// hintCmd runs logicalHint off the update loop.
+func hintCmd(rules *engine.Rules, geo engine.Geometry, grid, solution []uint8, puzzleID string) tea.Cmd {
+	return func() tea.Msg {
+		msg := hintMsg{puzzleID: puzzleID, grid: grid}
+		msg.place, msg.message, msg.note, msg.ok = logicalHint(rules, geo, grid, solution)
+		return msg
+	}
+}
+
+// handleHint applies a finished hint, unless the board changed while it
+// was searched, in which case the player can ask again.
+func (m *model) handleHint(msg hintMsg) {
+	m.hinting = false
+	if msg.puzzleID != m.puzzle.id || !slices.Equal(msg.grid, m.grid) || m.solved || m.gameOver {
 		return
 	}
-	if m.applyLogicalHint() {
+	size := m.set.size
+	if msg.ok && m.applyHintValue(msg.place.Cell/size, msg.place.Cell%size, msg.place.Digit, msg.message) {
+		m.hintNote = msg.note
 		m.autoSave()
 		return
 	}
+	m.revealHint()
+}
 
+// revealHint fills a random empty cell from the solution.
+func (m *model) revealHint() {
 	empties := make([]int, 0)
 	for i, value := range m.grid {
 		if value == 0 {
@@ -356,14 +498,14 @@ func (m *model) applyHint() {
 	if len(empties) == 0 {
 		return
 	}
-	index := empties[rng.Intn(len(empties))]
+	index := empties[m.session.rand.Intn(len(empties))]
 	row := index / m.set.size
 	col := index % m.set.size
 	if m.isFixed(row, col) {
 		return
 	}
 	m.pushUndo()
-	m.grid[index] = m.puzzle.solution[index]
+	m.setCell(index, m.puzzle.solution[index])
 	m.notes[index] = 0
 	m.pruneNotes(row, col, m.puzzle.solution[index])
 	m.hintsUsed++
@@ -372,49 +514,35 @@ func (m *model) applyHint() {
 	m.autoSave()
 }
 
-// applyLogicalHint applies a single logical hint if available.
-func (m *model) applyLogicalHint() bool {
-	candidates := make([]uint16, len(m.grid))
-	for i, value := range m.grid {
-		if value != 0 {
-			continue
-		}
-		row := i / m.set.size
-		col := i % m.set.size
-		candidates[i] = candidatesFor(m.grid, row, col, m.set)
-	}
-
-	for i, mask := range candidates {
-		if mask == 0 {
-			continue
-		}
-		if bitCount(mask) == 1 {
-			value := uint8(firstBit(mask))
-			row := i / m.set.size
-			col := i % m.set.size
-			return m.applyHintValue(row, col, value, "Hint: single candidate")
-		}
+// logicalHint finds the next placement the technique engine can justify
+// from a grid, with a hint message and an explanation of the hardest step
+// it needed for the status panel.
+func logicalHint(rules *engine.Rules, geo engine.Geometry, values, solution []uint8) (engine.Candidate, string, string, bool) {
+	grid, err := rules.NewGrid(values)
+	if err != nil {
+		return engine.Candidate{}, "", "", false
 	}
-
-	for row := 0; row < m.set.size; row++ {
-		if value, col, ok := hiddenSingleInRow(m.grid, candidates, m.set, row); ok {
-			return m.applyHintValue(row, col, value, "Hint: hidden single")
+	var hardest engine.Step
+	for {
+		step, ok := grid.NextStep()
+		if !ok {
+			return engine.Candidate{}, "", "", false
 		}
-	}
-	for col := 0; col < m.set.size; col++ {
-		if value, row, ok := hiddenSingleInCol(m.grid, candidates, m.set, col); ok {
-			return m.applyHintValue(row, col, value, "Hint: hidden single")
+		if step.Technique.Score() >= hardest.Technique.Score() {
+			hardest = step
 		}
-	}
-	for boxRow := 0; boxRow < m.set.size; boxRow += m.set.boxRows {
-		for boxCol := 0; boxCol < m.set.size; boxCol += m.set.boxCols {
-			if value, row, col, ok := hiddenSingleInBox(m.grid, candidates, m.set, boxRow, boxCol); ok {
-				return m.applyHintValue(row, col, value, "Hint: hidden single")
+		for _, c := range step.Placements {
+			if solution[c.Cell] != c.Digit {
+				return engine.Candidate{}, "", "", false
 			}
+			message := "Hint: " + strings.ToLower(step.Technique.String())
+			if hardest.Technique != step.Technique {
+				message += " after " + strings.ToLower(hardest.Technique.String())
+			}
+			return c, message, hardest.Describe(geo), true
 		}
+		grid.Apply(step)
 	}
-
-	return false
 }
 
 // applyHintValue fills a hinted value and tracks usage.
@@ -424,7 +552,7 @@ func (m *model) applyHintValue(row, col int, value uint8, message string) bool {
 	}
 	m.pushUndo()
 	index := idx(row, col, m.set.size)
-	m.grid[index] = value
+	m.setCell(index, value)
 	m.notes[index] = 0
 	m.pruneNotes(row, col, value)
 	m.hintsUsed++
@@ -433,86 +561,6 @@ func (m *model) applyHintValue(row, col int, value uint8, message string) bool {
 	return true
 }
 
-// hiddenSingleInRow searches for a hidden single in a row.
-func hiddenSingleInRow(grid []uint8, candidates []uint16, set puzzleSet, row int) (uint8, int, bool) {
-	counts := make([]int, set.size)
-	pos := make([]int, set.size)
-	for col := 0; col < set.size; col++ {
-		i := idx(row, col, set.size)
-		if grid[i] != 0 {
-			continue
-		}
-		mask := candidates[i]
-		for n := 0; n < set.size; n++ {
-			if mask&(1<<uint(n)) != 0 {
-				counts[n]++
-				pos[n] = col
-			}
-		}
-	}
-	for n, count := range counts {
-		if count == 1 {
-			return uint8(n + 1), pos[n], true
-		}
-	}
-	return 0, 0, false
-}
-
-// hiddenSingleInCol searches for a hidden single in a column.
-func hiddenSingleInCol(grid []uint8, candidates []uint16, set puzzleSet, col int) (uint8, int, bool) {
-	counts := make([]int, set.size)
-	pos := make([]int, set.size)
-	for row := 0; row < set.size; row++ {
-		i := idx(row, col, set.size)
-		if grid[i] != 0 {
-			continue
-		}
-		mask := candidates[i]
-		for n := 0; n < set.size; n++ {
-			if mask&(1<<uint(n)) != 0 {
-				counts[n]++
-				pos[n] = row
-			}
-		}
-	}
-	for n, count := range counts {
-		if count == 1 {
-			return uint8(n + 1), pos[n], true
-		}
-	}
-	return 0, 0, false
-}
-
-// hiddenSingleInBox searches for a hidden single in a box.
-func hiddenSingleInBox(grid []uint8, candidates []uint16, set puzzleSet, boxRow, boxCol int) (uint8, int, int, bool) {
-	counts := make([]int, set.size)
-	pos := make([]int, set.size)
-	for r := 0; r < set.boxRows; r++ {
-		for c := 0; c < set.boxCols; c++ {
-			row := boxRow + r
-			col := boxCol + c
-			i := idx(row, col, set.size)
-			if grid[i] != 0 {
-				continue
-			}
-			mask := candidates[i]
-			for n := 0; n < set.size; n++ {
-				if mask&(1<<uint(n)) != 0 {
-					counts[n]++
-					pos[n] = i
-				}
-			}
-		}
-	}
-	for n, count := range counts {
-		if count == 1 {
-			idxVal := pos[n]
-			return uint8(n + 1), idxVal / set.size, idxVal % set.size, true
-		}
-	}
-	return 0, 0, 0, false
-}
-
 // checkSolved updates solved state and best time.
 func (m *model) checkSolved() {
 	if m.solved || m.gameOver {
@@ -532,7 +580,7 @@ func (m *model) updateBestTime() {
 	if elapsed == 0 {
 		elapsed = int64(time.Since(m.start).Seconds())
 	}
-	key := statsKey(m.set.size, m.difficulty)
+	key := libraryKey(m.set, m.difficulty)
 	if best, ok := m.stats.Best[key]; !ok || elapsed < best {
 		if m.stats.Best == nil {
 			m.stats.Best = map[string]int64{}
@@ -1,423 +1,245 @@
 package sudoku
 
-// generatePuzzle produces a puzzle for the given size/difficulty.
-// It prefers curated puzzles and falls back to generated ones with uniqueness checks.
-func generatePuzzle(set puzzleSet, diff difficulty) puzzle {
-	if p, ok := randomFromLibrary(set, diff); ok {
-		return p
-	}
-
-	targetClues := clueCount(set.size, diff)
-	attempts := 0
-	for attempts < 60 {
-		attempts++
-		solution := generateSolution(set)
-		puzzleGrid := carvePuzzle(solution, set, targetClues)
-		if countSolutions(puzzleGrid, set, 2) != 1 {
-			continue
-		}
-		rated := rateDifficulty(puzzleGrid, set)
-		if rated == diff {
-			return puzzle{puzzle: puzzleGrid, solution: solution}
-		}
-	}
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
+)
+
+// generateTimeout bounds how long a single generation may run.
+const generateTimeout = 5 * time.Second
+
+// refillTimeout bounds a background refill, which runs on fewer workers
+// and keeps no one waiting.
+const refillTimeout = 4 * generateTimeout
+
+// spinnerInterval is how often the generating spinner advances.
+const spinnerInterval = 100 * time.Millisecond
 
-	solution := generateSolution(set)
-	puzzleGrid := carvePuzzle(solution, set, targetClues)
-	return puzzle{puzzle: puzzleGrid, solution: solution}
+// spinnerFrames are the frames of the generating spinner.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// puzzleMsg delivers a puzzle generated for the game in progress. The id
+// ties it to the request so results of superseded requests are pooled
+// instead of replacing the board.
+type puzzleMsg struct {
+	id     int
+	set    puzzleSet
+	diff   difficulty
+	puzzle puzzle
+	err    error
 }
 
-// carvePuzzle removes values from a solved grid while keeping uniqueness.
-func carvePuzzle(solution []uint8, set puzzleSet, targetClues int) []uint8 {
-	puzzleGrid := copyGrid(solution)
-	if targetClues < 0 {
-		targetClues = 0
-	}
-	if targetClues > len(puzzleGrid) {
-		targetClues = len(puzzleGrid)
-	}
-	removeCount := len(puzzleGrid) - targetClues
-	removed := 0
-	for _, idx := range rng.Perm(len(puzzleGrid)) {
-		if removed >= removeCount {
-			break
-		}
-		keep := puzzleGrid[idx]
-		puzzleGrid[idx] = 0
-		if countSolutions(puzzleGrid, set, 2) != 1 {
-			puzzleGrid[idx] = keep
-			continue
-		}
-		removed++
-	}
-	return puzzleGrid
+// poolMsg delivers a puzzle generated to refill the pool with a
+// symmetry.
+type poolMsg struct {
+	set    puzzleSet
+	diff   difficulty
+	sym    engine.Symmetry
+	puzzle puzzle
+	err    error
 }
 
-// generateSolution builds a full valid Sudoku solution by permuting a base grid.
-func generateSolution(set puzzleSet) []uint8 {
-	size := set.size
-	base := make([]uint8, size*size)
-	for row := 0; row < size; row++ {
-		for col := 0; col < size; col++ {
-			value := (row*set.boxCols + row/set.boxRows + col) % size
-			base[idx(row, col, size)] = uint8(value + 1)
-		}
-	}
+// refillMsg asks the model to start refilling the pool.
+type refillMsg struct{}
 
-	digitPerm := rng.Perm(size)
-	for i := range base {
-		base[i] = uint8(digitPerm[base[i]-1] + 1)
-	}
+// spinnerMsg advances the generating spinner.
+type spinnerMsg struct{}
 
-	rowOrder := shuffledBandIndices(size, set.boxRows)
-	colOrder := shuffledBandIndices(size, set.boxCols)
-	grid := make([]uint8, size*size)
-	for row := 0; row < size; row++ {
-		for col := 0; col < size; col++ {
-			grid[idx(row, col, size)] = base[idx(rowOrder[row], colOrder[col], size)]
-		}
-	}
-	return grid
+// generatePuzzle runs the engine generator for the given
+// size/difficulty/symmetry on up to workers cores, or all of them with
+// zero, serving a curated puzzle first when gen has one. When generation
+// times out it returns the error together with the closest puzzle found,
+// which is empty if no attempt finished. It touches no model state and
+// gen is safe for concurrent use, so it can run inside a tea.Cmd.
+func generatePuzzle(gen *engine.Generator, set puzzleSet, diff difficulty, sym engine.Symmetry, timeout time.Duration, workers int) (puzzle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	p, err := gen.Generate(ctx, set.geometry(), diff, engine.Options{Symmetry: sym, Variant: set.engineVariant(), Workers: workers})
+	return fromEngine(set, p), err
 }
 
-// shuffledBandIndices randomizes rows/cols by bands to preserve validity.
-func shuffledBandIndices(size, band int) []int {
-	bands := size / band
-	bandOrder := rng.Perm(bands)
-	order := make([]int, 0, size)
-	for _, b := range bandOrder {
-		rows := rng.Perm(band)
-		for _, r := range rows {
-			order = append(order, b*band+r)
-		}
-	}
-	return order
+// refillWorkers is how many cores a background refill uses: a quarter,
+// leaving the rest to the game in progress.
+func refillWorkers() int {
+	return max(1, runtime.GOMAXPROCS(0)/4)
 }
 
-// rateDifficulty estimates difficulty by running a logic-first solver.
-func rateDifficulty(puzzleGrid []uint8, set puzzleSet) difficulty {
-	solved, guesses := solveWithLogic(puzzleGrid, set)
-	if !solved {
-		return diffHard
+// fromEngine converts an engine puzzle into the game's puzzle. Curated
+// puzzles, which have no generator ID, get a library ID.
+func fromEngine(set puzzleSet, p engine.Puzzle) puzzle {
+	if p.Givens == nil {
+		return puzzle{}
 	}
-	if guesses == 0 {
-		return diffEasy
+	id := p.ID.String()
+	if p.ID == (engine.PuzzleID{}) {
+		id = libraryID(set, p)
 	}
-	if guesses <= 1 {
-		return diffMedium
+	return puzzle{
+		id:         id,
+		puzzle:     p.Givens,
+		solution:   p.Solution,
+		difficulty: p.Difficulty,
+		score:      p.Score,
+		symmetry:   p.Symmetry,
+		rules:      p.Rules,
 	}
-	return diffHard
 }
 
-// solveWithLogic runs a solver and returns whether it solved plus guess count.
-func solveWithLogic(puzzleGrid []uint8, set puzzleSet) (bool, int) {
-	grid := copyGrid(puzzleGrid)
-	solved, guesses := solveRecursive(grid, set)
-	return solved, guesses
-}
-
-// solveRecursive solves the puzzle using logic, falling back to search.
-func solveRecursive(grid []uint8, set puzzleSet) (bool, int) {
-	for {
-		progress, ok := applyLogic(grid, set)
-		if !ok {
-			return false, 0
-		}
-		if !progress {
-			break
-		}
+// puzzleFromID resolves a puzzle ID from the curated library or, failing
+// that, regenerates it with the engine.
+func puzzleFromID(id string) (puzzleSet, puzzle, error) {
+	if set, p, ok := libraryByID(id); ok {
+		return set, p, nil
 	}
-
-	emptyIndex := -1
-	var candidates uint16
-	minCount := 10
-	for i, value := range grid {
-		if value != 0 {
-			continue
-		}
-		row := i / set.size
-		col := i % set.size
-		mask := candidatesFor(grid, row, col, set)
-		count := bitCount(mask)
-		if count == 0 {
-			return false, 0
-		}
-		if count < minCount {
-			minCount = count
-			emptyIndex = i
-			candidates = mask
-			if count == 1 {
-				break
-			}
-		}
+	parsed, err := engine.ParsePuzzleID(id)
+	if err != nil {
+		return puzzleSet{}, puzzle{}, err
 	}
-
-	if emptyIndex == -1 {
-		return true, 0
+	set, ok := puzzleSets[parsed.Size]
+	if !ok {
+		return puzzleSet{}, puzzle{}, fmt.Errorf("%w: unsupported size %d", engine.ErrInvalidPuzzleID, parsed.Size)
 	}
-
-	row := emptyIndex / set.size
-	col := emptyIndex % set.size
-	for _, value := range maskToValues(candidates, set.size) {
-		next := copyGrid(grid)
-		next[idx(row, col, set.size)] = uint8(value)
-		solved, guesses := solveRecursive(next, set)
-		if solved {
-			return true, guesses + 1
-		}
-	}
-
-	return false, 0
-}
-
-// applyLogic fills obvious singles until no further progress is possible.
-func applyLogic(grid []uint8, set puzzleSet) (bool, bool) {
-	progress := false
-	for {
-		candidates := make([]uint16, len(grid))
-		for i, value := range grid {
-			if value != 0 {
-				continue
-			}
-			row := i / set.size
-			col := i % set.size
-			mask := candidatesFor(grid, row, col, set)
-			if mask == 0 {
-				return false, false
-			}
-			candidates[i] = mask
-		}
-
-		step := false
-		for i, mask := range candidates {
-			if mask == 0 {
-				continue
-			}
-			if bitCount(mask) == 1 {
-				value := firstBit(mask)
-				grid[i] = uint8(value)
-				step = true
-			}
-		}
-
-		for row := 0; row < set.size; row++ {
-			if applyHiddenSinglesRow(grid, candidates, set, row) {
-				step = true
-			}
-		}
-		for col := 0; col < set.size; col++ {
-			if applyHiddenSinglesCol(grid, candidates, set, col) {
-				step = true
-			}
-		}
-		for boxRow := 0; boxRow < set.size; boxRow += set.boxRows {
-			for boxCol := 0; boxCol < set.size; boxCol += set.boxCols {
-				if applyHiddenSinglesBox(grid, candidates, set, boxRow, boxCol) {
-					step = true
-				}
-			}
-		}
-
-		if !step {
-			break
-		}
-		progress = true
+	set = set.withVariant(parsed.Variant)
+	p, err := engine.GenerateFromID(parsed)
+	if err != nil {
+		return puzzleSet{}, puzzle{}, err
 	}
-	return true, progress
+	return set, fromEngine(set, p), nil
 }
 
-// applyHiddenSinglesRow finds hidden singles in a row.
-func applyHiddenSinglesRow(grid []uint8, candidates []uint16, set puzzleSet, row int) bool {
-	counts := make([]int, set.size)
-	pos := make([]int, set.size)
-	for col := 0; col < set.size; col++ {
-		i := idx(row, col, set.size)
-		if grid[i] != 0 {
-			continue
-		}
-		mask := candidates[i]
-		for n := 0; n < set.size; n++ {
-			if mask&(1<<uint(n)) != 0 {
-				counts[n]++
-				pos[n] = i
-			}
-		}
+// generateCmd generates a puzzle for the game in the background.
+func generateCmd(gen *engine.Generator, id int, set puzzleSet, diff difficulty, sym engine.Symmetry) tea.Cmd {
+	return func() tea.Msg {
+		p, err := generatePuzzle(gen, set, diff, sym, generateTimeout, 0)
+		return puzzleMsg{id: id, set: set, diff: diff, puzzle: p, err: err}
+	}
+}
+
+// refillCmd generates one puzzle for the pool in the background.
+func refillCmd(gen *engine.Generator, set puzzleSet, diff difficulty, sym engine.Symmetry) tea.Cmd {
+	return func() tea.Msg {
+		p, err := generatePuzzle(gen, set, diff, sym, refillTimeout, refillWorkers())
+		return poolMsg{set: set, diff: diff, sym: sym, puzzle: p, err: err}
+	}
+}
+
+// spinnerCmd schedules the next spinner frame.
+func spinnerCmd() tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg {
+		return spinnerMsg{}
+	})
+}
+
+// readyPuzzle returns a puzzle with the chosen symmetry that can be played
+// without generating, preferring the curated library and then the pool.
+func (m *model) readyPuzzle(set puzzleSet, diff difficulty) (puzzle, bool) {
+	if p, ok := m.session.generator(set.variant).Pick(set.geometry(), diff, m.symmetry); ok {
+		return fromEngine(set, p), true
 	}
-	progress := false
-	for n, count := range counts {
-		if count == 1 {
-			grid[pos[n]] = uint8(n + 1)
-			progress = true
-		}
+	if p, ok := m.session.pool.take(set, diff, m.symmetry); ok {
+		m.savePool()
+		return p, true
+	}
+	return puzzle{}, false
+}
+
+// refill starts generating the next pool puzzle unless one is already
+// being generated or the pool is full.
+func (m *model) refill() tea.Cmd {
+	if m.refilling {
+		return nil
+	}
+	diff, ok := m.session.pool.nextRefill(m.set, m.requested, m.symmetry, m.session.unreachable)
+	if !ok {
+		return nil
 	}
-	return progress
+	m.refilling = true
+	return refillCmd(m.session.generator(m.set.variant), m.set, diff, m.symmetry)
 }
 
-// applyHiddenSinglesCol finds hidden singles in a column.
-func applyHiddenSinglesCol(grid []uint8, candidates []uint16, set puzzleSet, col int) bool {
-	counts := make([]int, set.size)
-	pos := make([]int, set.size)
-	for row := 0; row < set.size; row++ {
-		i := idx(row, col, set.size)
-		if grid[i] != 0 {
-			continue
-		}
-		mask := candidates[i]
-		for n := 0; n < set.size; n++ {
-			if mask&(1<<uint(n)) != 0 {
-				counts[n]++
-				pos[n] = i
-			}
-		}
-	}
-	progress := false
-	for n, count := range counts {
-		if count == 1 {
-			grid[pos[n]] = uint8(n + 1)
-			progress = true
-		}
-	}
-	return progress
+// savePool persists the pool and its refill failure counts, ignoring
+// write errors like autoSave does.
+func (m *model) savePool() {
+	_ = savePool(m.session.pool, m.session.failures)
 }
 
-// applyHiddenSinglesBox finds hidden singles in a box.
-func applyHiddenSinglesBox(grid []uint8, candidates []uint16, set puzzleSet, boxRow, boxCol int) bool {
-	counts := make([]int, set.size)
-	pos := make([]int, set.size)
-	for r := 0; r < set.boxRows; r++ {
-		for c := 0; c < set.boxCols; c++ {
-			row := boxRow + r
-			col := boxCol + c
-			i := idx(row, col, set.size)
-			if grid[i] != 0 {
-				continue
-			}
-			mask := candidates[i]
-			for n := 0; n < set.size; n++ {
-				if mask&(1<<uint(n)) != 0 {
-					counts[n]++
-					pos[n] = i
-				}
-			}
-		}
-	}
-	progress := false
-	for n, count := range counts {
-		if count == 1 {
-			grid[pos[n]] = uint8(n + 1)
-			progress = true
+// handlePuzzle loads a generated puzzle, or pools it when the player has
+// since asked for a different one. When no generated puzzle rated as
+// requested, it falls back to a library puzzle of that difficulty in any
+// symmetry, and failing that loads the closest puzzle under its real
+// difficulty so the mismatch is shown instead of hidden.
+func (m *model) handlePuzzle(msg puzzleMsg) tea.Cmd {
+	if msg.id != m.genID || !m.generating {
+		if msg.err == nil && m.session.pool.add(msg.set, msg.diff, msg.puzzle) {
+			m.savePool()
 		}
+		return nil
+	}
+	m.generating = false
+	switch {
+	case msg.err == nil:
+		m.loadPuzzle(msg.set, msg.diff, msg.puzzle, "New puzzle")
+	case m.loadLibraryFallback(msg.set, msg.diff):
+	case msg.puzzle.puzzle == nil:
+		m.flash("Puzzle generation timed out")
+	default:
+		m.loadPuzzle(msg.set, msg.diff, msg.puzzle, "No "+difficultyLabel(msg.diff)+" puzzle found; closest loaded")
 	}
-	return progress
-}
+	return m.refill()
+}
 
-// countSolutions counts solutions up to a limit.
-func countSolutions(puzzleGrid []uint8, set puzzleSet, limit int) int {
-	grid := copyGrid(puzzleGrid)
-	return countSolutionsRecursive(grid, set, limit)
-}
-
-// countSolutionsRecursive explores solutions with backtracking.
-func countSolutionsRecursive(grid []uint8, set puzzleSet, limit int) int {
-	emptyIndex := -1
-	var candidates uint16
-	minCount := 10
-	for i, value := range grid {
-		if value != 0 {
-			continue
-		}
-		row := i / set.size
-		col := i % set.size
-		mask := candidatesFor(grid, row, col, set)
-		count := bitCount(mask)
-		if count == 0 {
-			return 0
-		}
-		if count < minCount {
-			minCount = count
-			emptyIndex = i
-			candidates = mask
-			if count == 1 {
-				break
-			}
-		}
-	}
-	if emptyIndex == -1 {
-		return 1
+// loadLibraryFallback loads a curated puzzle of the requested difficulty,
+// ignoring the symmetry preference, and reports whether one existed.
+func (m *model) loadLibraryFallback(set puzzleSet, diff difficulty) bool {
+	p, ok := m.session.generator(set.variant).Pick(set.geometry(), diff, engine.SymmetryNone)
+	if !ok {
+		return false
 	}
-	row := emptyIndex / set.size
-	col := emptyIndex % set.size
-	total := 0
-	for _, value := range maskToValues(candidates, set.size) {
-		grid[idx(row, col, set.size)] = uint8(value)
-		total += countSolutionsRecursive(grid, set, limit-total)
-		if total >= limit {
-			return total
-		}
-	}
-	grid[idx(row, col, set.size)] = 0
-	return total
+	m.loadPuzzle(set, diff, fromEngine(set, p), "Library puzzle loaded")
+	return true
 }
 
-// candidatesFor returns a bitmask of legal values for a cell.
-func candidatesFor(grid []uint8, row, col int, set puzzleSet) uint16 {
-	if grid[idx(row, col, set.size)] != 0 {
-		return 0
-	}
-	used := uint16(0)
-	for c := 0; c < set.size; c++ {
-		value := grid[idx(row, c, set.size)]
-		if value != 0 {
-			used |= 1 << uint(value-1)
-		}
-	}
-	for r := 0; r < set.size; r++ {
-		value := grid[idx(r, col, set.size)]
-		if value != 0 {
-			used |= 1 << uint(value-1)
-		}
-	}
-	boxRow := (row / set.boxRows) * set.boxRows
-	boxCol := (col / set.boxCols) * set.boxCols
-	for r := boxRow; r < boxRow+set.boxRows; r++ {
-		for c := boxCol; c < boxCol+set.boxCols; c++ {
-			value := grid[idx(r, c, set.size)]
-			if value != 0 {
-				used |= 1 << uint(value-1)
+// handlePool stores a pool puzzle and continues refilling; a puzzle the
+// player is waiting for is loaded straight away. Refills that finish
+// puzzles but none rated as requested are counted in the pool file, and
+// after unreachableAfter in a row the combination is skipped in this and
+// later runs. A refill cut short before any puzzle finished says more
+// about a busy machine than the combination, so it is only skipped for
+// the rest of this run.
+func (m *model) handlePool(msg poolMsg) tea.Cmd {
+	m.refilling = false
+	key := unreachableKey(msg.set, msg.diff, msg.sym)
+	if msg.err != nil {
+		switch {
+		case errors.Is(msg.err, context.Canceled):
+		case msg.puzzle.puzzle == nil:
+			m.session.unreachable[key] = true
+		default:
+			m.session.failures[key]++
+			if m.session.failures[key] >= unreachableAfter {
+				m.session.unreachable[key] = true
 			}
+			m.savePool()
 		}
+		return m.refill()
 	}
-	full := uint16(1<<uint(set.size)) - 1
-	return full &^ used
-}
-
-// bitCount returns the number of set bits in a mask.
-func bitCount(mask uint16) int {
-	count := 0
-	for mask > 0 {
-		mask &= mask - 1
-		count++
+	if m.session.failures[key] > 0 {
+		delete(m.session.failures, key)
+		m.savePool()
 	}
-	return count
-}
-
-// firstBit returns the 1-based index of the lowest set bit.
-func firstBit(mask uint16) int {
-	for i := 0; i < 16; i++ {
-		if mask&(1<<uint(i)) != 0 {
-			return i + 1
-		}
+	if m.generating && msg.set == m.genSet && msg.diff == m.genDiff && msg.puzzle.symmetry == m.symmetry {
+		m.generating = false
+		m.loadPuzzle(msg.set, msg.diff, msg.puzzle, "New puzzle")
+		return m.refill()
 	}
-	return 0
-}
-
-// maskToValues converts a mask to a list of candidate values.
-func maskToValues(mask uint16, size int) []int {
-	values := make([]int, 0, size)
-	for i := 0; i < size; i++ {
-		if mask&(1<<uint(i)) != 0 {
-			values = append(values, i+1)
-		}
+	if m.session.pool.add(msg.set, msg.diff, msg.puzzle) {
+		m.savePool()
 	}
-	return values
+	return m.refill()
 }
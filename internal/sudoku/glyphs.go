@@ -0,0 +1,124 @@
+package sudoku
+
+import (
+	"strconv"
+	"strings"
+)
+
+// glyphSet is an alphabet for showing and typing cell values.
+type glyphSet int
+
+const (
+	glyphsDecimal glyphSet = iota
+	glyphsAlnum
+	glyphsHex
+	glyphsLetters
+)
+
+// glyphSets lists the alphabets in the order the glyph key cycles them.
+var glyphSets = []glyphSet{glyphsDecimal, glyphsAlnum, glyphsHex, glyphsLetters}
+
+// glyphAlphabets holds the single-character symbols of each alphabet;
+// decimal labels are computed instead.
+var glyphAlphabets = map[glyphSet]string{
+	glyphsAlnum:   "123456789ABCDEFG",
+	glyphsHex:     "0123456789ABCDEF",
+	glyphsLetters: "ABCDEFGHIJKLMNOP",
+}
+
+// entryPrefix starts typing a value in full, for values without a key of
+// their own.
+const entryPrefix = "+"
+
+// String returns the display name of the alphabet.
+func (g glyphSet) String() string {
+	switch g {
+	case glyphsAlnum:
+		return "1-9 A-G"
+	case glyphsHex:
+		return "Hex 0-F"
+	case glyphsLetters:
+		return "Letters"
+	default:
+		return "Numbers"
+	}
+}
+
+// parseGlyphSet converts a saved name into an alphabet, defaulting to
+// decimal.
+func parseGlyphSet(value string) glyphSet {
+	for _, g := range glyphSets {
+		if strings.EqualFold(g.String(), value) {
+			return g
+		}
+	}
+	return glyphsDecimal
+}
+
+// nextGlyphSet cycles to the following alphabet.
+func nextGlyphSet(g glyphSet) glyphSet {
+	return glyphSets[(int(g)+1)%len(glyphSets)]
+}
+
+// label returns the symbol shown for a value from 1 to 16.
+func (g glyphSet) label(value int) string {
+	alphabet, ok := glyphAlphabets[g]
+	if !ok || value < 1 || value > len(alphabet) {
+		return strconv.Itoa(value)
+	}
+	return alphabet[value-1 : value]
+}
+
+// span describes the values of a board size, such as "1-9" or "0-F".
+func (g glyphSet) span(size int) string {
+	return g.label(1) + "-" + g.label(size)
+}
+
+// compact reports whether every label on a board this size is a single
+// character.
+func (g glyphSet) compact(size int) bool {
+	return g != glyphsDecimal || size <= 9
+}
+
+// directValue returns the value a single key enters on its own: the one
+// whose whole label is that key, in either case. Keys bound to a command
+// have none.
+func (g glyphSet) directValue(key string, size int) (int, bool) {
+	if commandKeys[key] {
+		return 0, false
+	}
+	for value := 1; value <= size; value++ {
+		if strings.EqualFold(g.label(value), key) {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// match looks up input typed after the entry prefix, ignoring case so
+// letters bound to commands can be typed. It returns the value whose
+// label equals input, whether that label is the only one starting with
+// input, and whether any label starts with input at all.
+func (g glyphSet) match(input string, size int) (int, bool, bool) {
+	input = strings.ToUpper(input)
+	found, longer := 0, false
+	for value := 1; value <= size; value++ {
+		label := strings.ToUpper(g.label(value))
+		switch {
+		case label == input:
+			found = value
+		case strings.HasPrefix(label, input):
+			longer = true
+		}
+	}
+	return found, found != 0 && !longer, found != 0 || longer
+}
+
+// commandKeys are the single keys bound to game commands, which value
+// entry must not shadow.
+var commandKeys = map[string]bool{
+	"q": true, "n": true, "r": true, "s": true, "m": true, "d": true,
+	"S": true, "p": true, "v": true, "u": true, "y": true, "c": true,
+	"w": true, "o": true, "?": true, "h": true, "j": true, "k": true,
+	"l": true, "H": true, "g": true, "V": true, entryPrefix: true,
+}
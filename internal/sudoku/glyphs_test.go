@@ -0,0 +1,173 @@
+package sudoku
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// TestGlyphLabels checks the symbols each alphabet shows.
+func TestGlyphLabels(t *testing.T) {
+	cases := []struct {
+		g     glyphSet
+		value int
+		want  string
+	}{
+		{glyphsDecimal, 9, "9"},
+		{glyphsDecimal, 16, "16"},
+		{glyphsAlnum, 10, "A"},
+		{glyphsAlnum, 16, "G"},
+		{glyphsHex, 1, "0"},
+		{glyphsHex, 16, "F"},
+		{glyphsLetters, 1, "A"},
+		{glyphsLetters, 16, "P"},
+	}
+	for _, c := range cases {
+		if got := c.g.label(c.value); got != c.want {
+			t.Errorf("%v label(%d) = %q, want %q", c.g, c.value, got, c.want)
+		}
+	}
+}
+
+// TestDirectValue checks which single keys enter a value on their own.
+func TestDirectValue(t *testing.T) {
+	cases := []struct {
+		g     glyphSet
+		key   string
+		size  int
+		value int
+		ok    bool
+	}{
+		{glyphsDecimal, "7", 9, 7, true},
+		{glyphsDecimal, "1", 16, 1, true},
+		{glyphsDecimal, "0", 16, 0, false},
+		{glyphsAlnum, "b", 12, 11, true},
+		{glyphsAlnum, "G", 16, 16, true},
+		{glyphsAlnum, "G", 12, 0, false},
+		{glyphsHex, "0", 16, 1, true},
+		{glyphsHex, "E", 16, 15, true},
+		{glyphsLetters, "a", 9, 1, true},
+		// Command keys never enter a value, whatever their letter.
+		{glyphsHex, "d", 16, 0, false},
+		{glyphsLetters, "n", 16, 0, false},
+		{glyphsLetters, "H", 16, 0, false},
+	}
+	for _, c := range cases {
+		value, ok := c.g.directValue(c.key, c.size)
+		if value != c.value || ok != c.ok {
+			t.Errorf("%v directValue(%q, %d) = %d, %v, want %d, %v", c.g, c.key, c.size, value, ok, c.value, c.ok)
+		}
+	}
+}
+
+// TestPrefixEntry checks matching typed input after the entry prefix,
+// including letters that are also commands.
+func TestPrefixEntry(t *testing.T) {
+	cases := []struct {
+		g        glyphSet
+		input    string
+		size     int
+		value    int
+		complete bool
+		valid    bool
+	}{
+		{glyphsDecimal, "1", 16, 1, false, true},
+		{glyphsDecimal, "16", 16, 16, true, true},
+		{glyphsDecimal, "2", 16, 2, true, true},
+		{glyphsDecimal, "17", 16, 0, false, false},
+		{glyphsDecimal, "1", 9, 1, true, true},
+		{glyphsHex, "d", 16, 14, true, true},
+		{glyphsLetters, "h", 16, 8, true, true},
+		{glyphsLetters, "Z", 16, 0, false, false},
+	}
+	for _, c := range cases {
+		value, complete, valid := c.g.match(c.input, c.size)
+		if value != c.value || complete != c.complete || valid != c.valid {
+			t.Errorf("%v match(%q, %d) = %d, %v, %v, want %d, %v, %v",
+				c.g, c.input, c.size, value, complete, valid, c.value, c.complete, c.valid)
+		}
+	}
+}
+
+// TestCommandKeysMatchUpdate checks commandKeys against the single keys
+// Update binds during play, so a new binding cannot be typed as a glyph.
+func TestCommandKeysMatchUpdate(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "update.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		fn, ok := n.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "Update" {
+			return true
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SwitchStmt:
+				if playSwitch(n) {
+					for _, stmt := range n.Body.List {
+						for _, expr := range stmt.(*ast.CaseClause).List {
+							addKey(bound, expr)
+						}
+					}
+				}
+			case *ast.BinaryExpr:
+				// Keys Update checks as runes after the switch, such as r == 'H'.
+				if id, ok := n.X.(*ast.Ident); ok && id.Name == "r" && n.Op == token.EQL {
+					addKey(bound, n.Y)
+				}
+			}
+			return true
+		})
+		return false
+	})
+	if len(bound) == 0 {
+		t.Fatal("found no key bindings in Update")
+	}
+	want := slices.Sorted(maps.Keys(bound))
+	got := slices.Sorted(maps.Keys(commandKeys))
+	if !slices.Equal(got, want) {
+		t.Errorf("commandKeys = %q, Update binds %q", got, want)
+	}
+}
+
+// playSwitch reports whether a switch is the one dispatching keys during
+// play, which is the only one binding the entry prefix.
+func playSwitch(s *ast.SwitchStmt) bool {
+	for _, stmt := range s.Body.List {
+		for _, expr := range stmt.(*ast.CaseClause).List {
+			if id, ok := expr.(*ast.Ident); ok && id.Name == "entryPrefix" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addKey records a case label or rune literal that names a single key.
+func addKey(keys map[string]bool, expr ast.Expr) {
+	if id, ok := expr.(*ast.Ident); ok && id.Name == "entryPrefix" {
+		keys[entryPrefix] = true
+		return
+	}
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok {
+		return
+	}
+	var key string
+	switch lit.Kind {
+	case token.STRING:
+		key, _ = strconv.Unquote(lit.Value)
+	case token.CHAR:
+		r, _, _, _ := strconv.UnquoteChar(lit.Value[1:len(lit.Value)-1], '\'')
+		key = string(r)
+	}
+	if len([]rune(key)) == 1 && key != " " {
+		keys[key] = true
+	}
+}
@@ -3,8 +3,13 @@ package sudoku
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"os"
+	"slices"
 	"strings"
+	"sync"
+
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
 )
 
 // puzzleEntry is a JSON entry for a curated puzzle.
@@ -13,6 +18,11 @@ type puzzleEntry struct {
 	Difficulty string  `json:"difficulty"`
 	Puzzle     []uint8 `json:"puzzle"`
 	Solution   []uint8 `json:"solution"`
+	Score      float64 `json:"score,omitempty"`
+	Symmetry   string  `json:"symmetry,omitempty"`
+	ID         string  `json:"id,omitempty"`
+	Variant    string  `json:"variant,omitempty"`
+	variantData
 }
 
 // puzzleLibrary is the JSON payload for curated puzzles.
@@ -20,34 +30,64 @@ type puzzleLibrary struct {
 	Puzzles []puzzleEntry `json:"puzzles"`
 }
 
+// curated is the puzzles.json library split by variant, loaded once on
+// first use and read-only afterwards; curatedIDs indexes it by puzzle ID.
 var (
-	libraryLoaded bool
-	libraryByKey  map[string][]puzzle
+	curatedOnce sync.Once
+	curated     map[string]*engine.MemoryLibrary
+	curatedIDs  map[string]curatedPuzzle
 )
 
-// randomFromLibrary returns a puzzle from the curated library when available.
-func randomFromLibrary(set puzzleSet, diff difficulty) (puzzle, bool) {
-	loadLibrary()
-	key := libraryKey(set.size, diff)
-	list := libraryByKey[key]
-	if len(list) == 0 {
-		return puzzle{}, false
+// curatedPuzzle is a library puzzle and the set it is played on.
+type curatedPuzzle struct {
+	set    puzzleSet
+	puzzle engine.Puzzle
+}
+
+// curatedLibrary returns the curated puzzles of a variant, loading the
+// library on first use.
+func curatedLibrary(variant string) *engine.MemoryLibrary {
+	curatedOnce.Do(loadLibrary)
+	if lib, ok := curated[variant]; ok {
+		return lib
 	}
-	return list[rng.Intn(len(list))], true
+	return engine.NewMemoryLibrary()
 }
 
-// libraryKey creates the lookup key used for curated puzzles.
-func libraryKey(size int, diff difficulty) string {
-	return fmt.Sprintf("%dx%d:%s", size, size, strings.ToLower(difficultyLabel(diff)))
+// libraryHas reports whether the curated library covers a
+// size/difficulty/symmetry combination.
+func libraryHas(set puzzleSet, diff difficulty, sym engine.Symmetry) bool {
+	geo := set.geometry()
+	for _, p := range curatedLibrary(set.variant).Puzzles(geo, diff) {
+		if sym.Matches(geo, p.Givens) {
+			return true
+		}
+	}
+	return false
 }
 
-// loadLibrary reads puzzles.json and indexes valid entries by size/difficulty.
+// libraryKey creates the lookup key used for pooled puzzles and stats,
+// such as "9x9:hard". Variants keep their own entries under a prefixed
+// key, such as "killer:9x9:hard".
+func libraryKey(set puzzleSet, diff difficulty) string {
+	key := fmt.Sprintf("%dx%d:%s", set.size, set.size, strings.ToLower(difficultyLabel(diff)))
+	if set.variant != variantClassic {
+		key = set.variant + ":" + key
+	}
+	return key
+}
+
+// loadLibrary reads puzzles.json and indexes valid entries by variant,
+// geometry and rated difficulty. Entries are solved under their
+// variant's rules and skipped unless the unique solution matches the
+// stored one, then re-rated with the technique engine so curated and
+// generated puzzles share the same labels.
 func loadLibrary() {
-	if libraryLoaded {
-		return
+	curated = map[string]*engine.MemoryLibrary{}
+	curatedIDs = map[string]curatedPuzzle{}
+	for _, variant := range gameVariants() {
+		curated[variant] = engine.NewMemoryLibrary()
 	}
-	libraryLoaded = true
-	libraryByKey = map[string][]puzzle{}
 
 	data, err := os.ReadFile(puzzlesFile)
 	if err != nil {
@@ -59,22 +99,71 @@ func loadLibrary() {
 	}
 	for _, entry := range lib.Puzzles {
 		set, ok := puzzleSets[entry.Size]
+		if !ok || !validEntry(entry, set) {
+			continue
+		}
+		set = set.withVariant(entry.Variant)
+		rules, ok := variantRulesFor(set, entry.variantData)
 		if !ok {
 			continue
 		}
-		diff := parseDifficulty(entry.Difficulty)
-		if !validEntry(entry, set) {
+		if rules == nil {
+			if rules, err = set.rules(); err != nil {
+				continue
+			}
+		}
+		solution, err := rules.Solve(entry.Puzzle)
+		if err != nil || !slices.Equal(solution, engine.Board(entry.Solution)) {
+			continue
+		}
+		rating, err := rules.Rate(entry.Puzzle)
+		if err != nil {
 			continue
 		}
-		if countSolutions(entry.Puzzle, set, 2) != 1 {
+		p := engine.Puzzle{
+			Geometry:   set.geometry(),
+			Givens:     entry.Puzzle,
+			Solution:   entry.Solution,
+			Difficulty: rating.Difficulty,
+			Score:      rating.Score,
+		}
+		if set.variant != variantClassic {
+			p.Rules = rules
+		}
+		// A duplicate ID would resolve to the wrong puzzle, so only the
+		// first entry with a given ID is kept.
+		id := libraryID(set, p)
+		if _, dup := curatedIDs[id]; dup {
 			continue
 		}
-		key := libraryKey(entry.Size, diff)
-		libraryByKey[key] = append(libraryByKey[key], puzzle{
-			puzzle:   entry.Puzzle,
-			solution: entry.Solution,
-		})
+		curated[set.variant].Add(p)
+		curatedIDs[id] = curatedPuzzle{set: set, puzzle: p}
+	}
+}
+
+// libraryID derives a stable ID for a curated puzzle from its givens
+// and, outside classic play, its variant and the variant's data, so it
+// survives reordering of puzzles.json and variant puzzles sharing givens
+// get distinct IDs.
+func libraryID(set puzzleSet, p engine.Puzzle) string {
+	h := fnv.New32a()
+	h.Write(p.Givens)
+	if set.variant != variantClassic {
+		data, _ := json.Marshal(variantDataOf(set, p.Rules))
+		h.Write([]byte(set.variant))
+		h.Write(data)
+	}
+	return fmt.Sprintf("lib-%08x", h.Sum32())
+}
+
+// libraryByID finds a curated puzzle by its ID.
+func libraryByID(id string) (puzzleSet, puzzle, bool) {
+	curatedLibrary(variantClassic)
+	p, ok := curatedIDs[id]
+	if !ok {
+		return puzzleSet{}, puzzle{}, false
 	}
+	return p.set, fromEngine(p.set, p.puzzle), true
 }
 
 // validEntry ensures the puzzle/solution are consistent with size constraints.
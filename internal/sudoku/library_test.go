@@ -0,0 +1,26 @@
+package sudoku
+
+import (
+	"testing"
+
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
+)
+
+// TestLibraryKey checks the keys shared by the pool and best times.
+func TestLibraryKey(t *testing.T) {
+	cases := []struct {
+		set  puzzleSet
+		d    difficulty
+		want string
+	}{
+		{puzzleSets[9], engine.Hard, "9x9:hard"},
+		{puzzleSets[16].withVariant(variantClassic), engine.Easy, "16x16:easy"},
+		{puzzleSets[9].withVariant("x"), engine.Medium, "x:9x9:medium"},
+		{puzzleSets[6].withVariant("killer"), engine.Diabolical, "killer:6x6:diabolical"},
+	}
+	for _, c := range cases {
+		if got := libraryKey(c.set, c.d); got != c.want {
+			t.Errorf("libraryKey(%+v, %s) = %q, want %q", c.set, c.d, got, c.want)
+		}
+	}
+}
@@ -1,12 +1,19 @@
 package sudoku
 
-import "time"
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
+)
 
 // model is the Bubble Tea state container for the game.
 type model struct {
 	set            puzzleSet
 	puzzle         puzzle
 	grid           []uint8
+	state          *engine.State
 	notes          []uint16
 	row            int
 	col            int
@@ -14,11 +21,17 @@ type model struct {
 	width          int
 	height         int
 	difficulty     difficulty
+	requested      difficulty
+	symmetry       engine.Symmetry
+	glyphs         glyphSet
+	entering       bool
+	entryInput     string
 	mistakes       int
 	hintsUsed      int
 	noteMode       bool
 	showConflicts  bool
 	selectingSize  bool
+	sizeInput      string
 	showHelp       bool
 	solved         bool
 	elapsedAtSolve int64
@@ -33,6 +46,58 @@ type model struct {
 	stats          stats
 	flashMessage   string
 	flashUntil     time.Time
+	hintNote       string
+	hinting        bool
+	generating     bool
+	genID          int
+	genSet         puzzleSet
+	genDiff        difficulty
+	spinner        int
+	refilling      bool
+	session        *session
+}
+
+// session holds what every copy of the model shares during one run.
+// The generators are safe for concurrent use; the rest is only touched
+// from Update.
+type session struct {
+	gens map[string]*engine.Generator
+	pool puzzlePool
+	// failures counts refill misses in a row per unreachableKey.
+	failures map[string]int
+	// unreachable holds the keys refilling skips for the rest of the run.
+	unreachable map[string]bool
+	// rand picks cells for hint reveals.
+	rand *rand.Rand
+}
+
+// newSession loads the pool and builds one generator per variant over
+// that variant's curated puzzles.
+func newSession() *session {
+	seed := time.Now().UnixNano()
+	pool, failures := loadPool()
+	unreachable := map[string]bool{}
+	for key, n := range failures {
+		if n >= unreachableAfter {
+			unreachable[key] = true
+		}
+	}
+	sess := &session{
+		gens:        map[string]*engine.Generator{},
+		pool:        pool,
+		failures:    failures,
+		unreachable: unreachable,
+		rand:        rand.New(rand.NewSource(seed + 1)),
+	}
+	for i, variant := range gameVariants() {
+		sess.gens[variant] = engine.NewGenerator(rand.NewSource(seed+int64(2*i)), curatedLibrary(variant))
+	}
+	return sess
+}
+
+// generator returns the generator for a variant's puzzles.
+func (s *session) generator(variant string) *engine.Generator {
+	return s.gens[variant]
 }
 
 // slotMode indicates whether the slot prompt is saving or loading.
@@ -64,6 +129,12 @@ type saveState struct {
 	BoxRows       int      `json:"box_rows"`
 	BoxCols       int      `json:"box_cols"`
 	Difficulty    string   `json:"difficulty"`
+	Requested     string   `json:"requested,omitempty"`
+	Score         float64  `json:"score,omitempty"`
+	PuzzleID      string   `json:"puzzle_id,omitempty"`
+	Symmetry      string   `json:"symmetry,omitempty"`
+	Glyphs        string   `json:"glyphs,omitempty"`
+	Variant       string   `json:"variant,omitempty"`
 	Puzzle        []uint8  `json:"puzzle"`
 	Solution      []uint8  `json:"solution"`
 	Grid          []uint8  `json:"grid"`
@@ -79,6 +150,7 @@ type saveState struct {
 	Elapsed       int64    `json:"elapsed"`
 	StrictMode    bool     `json:"strict_mode"`
 	GameOver      bool     `json:"game_over"`
+	variantData
 }
 
 // saveSlots stores all slot saves in one file.
@@ -90,27 +162,78 @@ type saveSlots struct {
 // tickMsg drives timer updates.
 type tickMsg time.Time
 
-// NewModel constructs the initial game model (loads save if present).
-func NewModel() model {
+// NewModel constructs the initial game model, resuming the active save
+// when it loads and starting a new game otherwise.
+func NewModel() (model, error) {
 	st := loadStats()
+	sess := newSession()
 	if saved, slot, ok := loadActiveSave(); ok {
-		return modelFromSave(saved, st, slot)
+		if m, err := modelFromSave(saved, st, slot); err == nil {
+			m.session = sess
+			return m, nil
+		}
 	}
 
 	set := puzzleSets[6]
 	diff := diffEasy
-	p := generatePuzzle(set, diff)
+	m := freshModel(set, diff, st, sess)
+	p, ok := m.readyPuzzle(set, diff)
+	if !ok {
+		// Nothing is ready on a first run; wait for the generator once.
+		full, _ := sess.generator(set.variant).Generate(context.Background(), set.geometry(), diff, engine.Options{})
+		p = fromEngine(set, full)
+	}
+	if err := m.usePuzzle(p); err != nil {
+		return model{}, err
+	}
+	return m, nil
+}
+
+// NewModelFromID starts a new game on the puzzle an ID names, either a
+// curated library ID or a generator ID that is carved again from its seed.
+func NewModelFromID(id string) (model, error) {
+	set, p, err := puzzleFromID(id)
+	if err != nil {
+		return model{}, err
+	}
+	m := freshModel(set, p.difficulty, loadStats(), newSession())
+	if _, slot, ok := loadActiveSave(); ok {
+		m.activeSlot = slot
+	}
+	m.symmetry = p.symmetry
+	if err := m.usePuzzle(p); err != nil {
+		return model{}, err
+	}
+	return m, nil
+}
+
+// freshModel builds a model with default settings and no puzzle yet.
+func freshModel(set puzzleSet, diff difficulty, st stats, sess *session) model {
 	return model{
 		set:           set,
-		puzzle:        p,
-		grid:          copyGrid(p.puzzle),
 		notes:         make([]uint16, set.size*set.size),
 		row:           0,
 		col:           0,
 		start:         time.Now(),
 		difficulty:    diff,
+		requested:     diff,
 		showConflicts: true,
 		activeSlot:    1,
 		stats:         st,
+		session:       sess,
+	}
+}
+
+// usePuzzle installs the first puzzle of a fresh model. It fails when the
+// puzzle has no rules for the model's set.
+func (m *model) usePuzzle(p puzzle) error {
+	grid, state, err := newState(m.set, p, copyGrid(p.puzzle))
+	if err != nil {
+		return err
 	}
+	m.puzzle = p
+	m.difficulty = p.difficulty
+	m.grid = grid
+	m.state = state
+	return nil
 }
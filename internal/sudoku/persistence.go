@@ -2,6 +2,7 @@ package sudoku
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -20,6 +21,13 @@ func (m *model) makeSaveState() saveState {
 		BoxRows:       m.set.boxRows,
 		BoxCols:       m.set.boxCols,
 		Difficulty:    strings.ToLower(difficultyLabel(m.difficulty)),
+		Requested:     m.requestedLabel(),
+		Score:         m.puzzle.score,
+		PuzzleID:      m.puzzle.id,
+		Symmetry:      m.puzzle.symmetry.String(),
+		Glyphs:        m.glyphs.String(),
+		Variant:       m.set.variant,
+		variantData:   variantDataOf(m.set, m.puzzle.rules),
 		Puzzle:        copyGrid(m.puzzle.puzzle),
 		Solution:      copyGrid(m.puzzle.solution),
 		Grid:          copyGrid(m.grid),
@@ -38,6 +46,15 @@ func (m *model) makeSaveState() saveState {
 	}
 }
 
+// requestedLabel returns the requested difficulty for saves, or "" when
+// the puzzle matches it.
+func (m *model) requestedLabel() string {
+	if m.requested == m.difficulty {
+		return ""
+	}
+	return strings.ToLower(difficultyLabel(m.requested))
+}
+
 // loadActiveSave returns the active slot's save if present.
 func loadActiveSave() (saveState, int, bool) {
 	slots := loadSlotsFile()
@@ -95,22 +112,37 @@ func saveSlotsFile(slots saveSlots) error {
 	return os.WriteFile(savesFile, data, 0o644)
 }
 
-// modelFromSave reconstructs a model from a saved state.
-func modelFromSave(state saveState, st stats, slot int) model {
+// modelFromSave reconstructs a model from a saved state. It fails when
+// the saved variant's rules cannot be rebuilt, since its givens and
+// solution only fit those rules, or when the puzzle has no rules for its
+// board.
+func modelFromSave(state saveState, st stats, slot int) (model, error) {
 	set, ok := puzzleSets[state.Size]
 	if !ok {
 		set = puzzleSets[6]
 	}
+	set = set.withVariant(state.Variant)
+	rules, ok := variantRulesFor(set, state.variantData)
+	if !ok {
+		return model{}, fmt.Errorf("invalid %s rules", state.Variant)
+	}
 	diff := parseDifficulty(state.Difficulty)
+	requested := diff
+	if state.Requested != "" {
+		requested = parseDifficulty(state.Requested)
+	}
+	sym := parseSymmetry(state.Symmetry)
 	m := model{
 		set:            set,
-		puzzle:         puzzle{puzzle: state.Puzzle, solution: state.Solution},
-		grid:           state.Grid,
+		puzzle:         puzzle{id: state.PuzzleID, puzzle: state.Puzzle, solution: state.Solution, difficulty: diff, score: state.Score, symmetry: sym, rules: rules},
 		notes:          state.Notes,
 		row:            state.Row,
 		col:            state.Col,
 		start:          time.Unix(state.StartUnix, 0),
 		difficulty:     diff,
+		requested:      requested,
+		symmetry:       sym,
+		glyphs:         parseGlyphSet(state.Glyphs),
 		mistakes:       state.Mistakes,
 		hintsUsed:      state.HintsUsed,
 		noteMode:       state.NoteMode,
@@ -123,9 +155,12 @@ func modelFromSave(state saveState, st stats, slot int) model {
 		stats:          st,
 	}
 	expected := set.size * set.size
-	if len(m.grid) != expected {
-		m.grid = copyGrid(m.puzzle.puzzle)
+	grid, gameState, err := newState(set, m.puzzle, state.Grid)
+	if err != nil {
+		return model{}, err
 	}
+	m.grid = grid
+	m.state = gameState
 	if len(m.notes) != expected {
 		m.notes = make([]uint16, expected)
 	}
@@ -133,7 +168,23 @@ func modelFromSave(state saveState, st stats, slot int) model {
 		m.elapsedAtSolve = int64(time.Since(m.start).Seconds())
 	}
 	m.solved = m.solved || m.isSolved()
-	return m
+	return m, nil
+}
+
+// withSave loads a saved game while keeping session state such as the
+// window size, stats, pool and any generation in flight. It fails when
+// the save does not load.
+func (m model) withSave(state saveState, slot int) (model, error) {
+	loaded, err := modelFromSave(state, m.stats, slot)
+	if err != nil {
+		return m, err
+	}
+	loaded.width = m.width
+	loaded.height = m.height
+	loaded.session = m.session
+	loaded.refilling = m.refilling
+	loaded.genID = m.genID
+	return loaded, nil
 }
 
 // loadStats loads best-time stats.
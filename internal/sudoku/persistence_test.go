@@ -0,0 +1,53 @@
+package sudoku
+
+import "testing"
+
+// TestModelFromSave checks that a save loads with its variant's rules and
+// that one whose rules cannot be rebuilt is refused.
+func TestModelFromSave(t *testing.T) {
+	solution := []uint8{
+		1, 2, 3, 4,
+		3, 4, 1, 2,
+		2, 1, 4, 3,
+		4, 3, 2, 1,
+	}
+	puzzle := append([]uint8(nil), solution...)
+	puzzle[0], puzzle[5], puzzle[10], puzzle[15] = 0, 0, 0, 0
+	boxes := []int{
+		0, 0, 1, 1,
+		0, 0, 1, 1,
+		2, 2, 3, 3,
+		2, 2, 3, 3,
+	}
+	cases := []struct {
+		name    string
+		variant string
+		data    variantData
+		ok      bool
+	}{
+		{"classic", variantClassic, variantData{}, true},
+		{"jigsaw", "jigsaw", variantData{Boxes: boxes}, true},
+		{"jigsaw without boxes", "jigsaw", variantData{}, false},
+		{"killer without cages", "killer", variantData{}, false},
+		{"unknown variant", "sandwich", variantData{}, false},
+	}
+	for _, c := range cases {
+		state := saveState{
+			Size:        4,
+			Difficulty:  "easy",
+			Variant:     c.variant,
+			Puzzle:      puzzle,
+			Solution:    solution,
+			Grid:        puzzle,
+			variantData: c.data,
+		}
+		m, err := modelFromSave(state, stats{}, 1)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: error %v", c.name, err)
+			continue
+		}
+		if c.ok && (m.set.variant != c.variant || m.state == nil) {
+			t.Errorf("%s: loaded as %q", c.name, m.set.variant)
+		}
+	}
+}
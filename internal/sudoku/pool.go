@@ -0,0 +1,180 @@
+package sudoku
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
+)
+
+// poolTarget is how many ready puzzles the pool keeps per size/difficulty.
+const poolTarget = 3
+
+// unreachableAfter is how many refills of a key must fail in a row
+// before refilling gives up on it.
+const unreachableAfter = 3
+
+// puzzlePool keeps generated puzzles ready, keyed by poolKey, so a new
+// game starts instantly. Each key holds up to poolTarget puzzles per
+// symmetry, and only for combinations the curated library does not cover.
+type puzzlePool map[string][]puzzle
+
+// poolData is the JSON form of the pool file: the pooled puzzles in the
+// library's format, plus how many refills in a row of each key, from
+// unreachableKey, the generator version named by Version failed to rate
+// as requested.
+type poolData struct {
+	puzzleLibrary
+	Version  int            `json:"version,omitempty"`
+	Failures map[string]int `json:"failures,omitempty"`
+}
+
+// loadPool reads the pool file and its refill failure counts, skipping
+// entries that do not fit their size and anything from another generator
+// version, whose labels and reach may be stale.
+func loadPool() (puzzlePool, map[string]int) {
+	pool, failures := puzzlePool{}, map[string]int{}
+	data, err := os.ReadFile(poolFile)
+	if err != nil {
+		return pool, failures
+	}
+	var lib poolData
+	if err := json.Unmarshal(data, &lib); err != nil {
+		return pool, failures
+	}
+	if lib.Version == engine.GeneratorVersion {
+		for key, n := range lib.Failures {
+			failures[key] = n
+		}
+	}
+	for _, entry := range lib.Puzzles {
+		set, ok := puzzleSets[entry.Size]
+		if !ok || !validEntry(entry, set) || !currentID(entry.ID) {
+			continue
+		}
+		set = set.withVariant(entry.Variant)
+		rules, ok := variantRulesFor(set, entry.variantData)
+		if !ok {
+			continue
+		}
+		key := libraryKey(set, parseDifficulty(entry.Difficulty))
+		pool[key] = append(pool[key], puzzle{
+			id:         entry.ID,
+			puzzle:     entry.Puzzle,
+			solution:   entry.Solution,
+			difficulty: parseDifficulty(entry.Difficulty),
+			score:      entry.Score,
+			symmetry:   parseSymmetry(entry.Symmetry),
+			rules:      rules,
+		})
+	}
+	return pool, failures
+}
+
+// currentID reports whether a generated puzzle ID comes from the running
+// generator version.
+func currentID(id string) bool {
+	parsed, err := engine.ParsePuzzleID(id)
+	return err == nil && parsed.Version == engine.GeneratorVersion
+}
+
+// unreachableKey keys a refill the generator could not reach, such as
+// "killer:9x9:hard:Rotate 180".
+func unreachableKey(set puzzleSet, diff difficulty, sym engine.Symmetry) string {
+	return libraryKey(set, diff) + ":" + sym.String()
+}
+
+// savePool writes the pool file: the puzzles in the library's JSON format
+// and the refill failure counts.
+func savePool(pool puzzlePool, failures map[string]int) error {
+	lib := poolData{Version: engine.GeneratorVersion, Failures: failures}
+	for _, variant := range gameVariants() {
+		for _, size := range sortedSizes() {
+			set := puzzleSets[size].withVariant(variant)
+			for _, diff := range engine.Difficulties {
+				for _, p := range pool[libraryKey(set, diff)] {
+					lib.Puzzles = append(lib.Puzzles, puzzleEntry{
+						Size:        size,
+						Difficulty:  strings.ToLower(difficultyLabel(diff)),
+						Puzzle:      p.puzzle,
+						Solution:    p.solution,
+						Score:       p.score,
+						Symmetry:    p.symmetry.String(),
+						ID:          p.id,
+						Variant:     variant,
+						variantData: variantDataOf(set, p.rules),
+					})
+				}
+			}
+		}
+	}
+	data, err := json.MarshalIndent(lib, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(poolFile, data, 0o644)
+}
+
+// take removes and returns a ready puzzle for a size/difficulty/symmetry.
+func (pool puzzlePool) take(set puzzleSet, diff difficulty, sym engine.Symmetry) (puzzle, bool) {
+	key := libraryKey(set, diff)
+	list := pool[key]
+	for i, p := range list {
+		if p.symmetry == sym {
+			pool[key] = append(list[:i:i], list[i+1:]...)
+			return p, true
+		}
+	}
+	return puzzle{}, false
+}
+
+// add stores a puzzle when its size/difficulty/symmetry is below target
+// and reports whether it was kept.
+func (pool puzzlePool) add(set puzzleSet, diff difficulty, p puzzle) bool {
+	if p.puzzle == nil || !pool.needs(set, diff, p.symmetry) {
+		return false
+	}
+	key := libraryKey(set, diff)
+	pool[key] = append(pool[key], p)
+	return true
+}
+
+// needs reports whether a size/difficulty/symmetry is below target and
+// not covered by the curated library.
+func (pool puzzlePool) needs(set puzzleSet, diff difficulty, sym engine.Symmetry) bool {
+	count := 0
+	for _, p := range pool[libraryKey(set, diff)] {
+		if p.symmetry == sym {
+			count++
+		}
+	}
+	return count < poolTarget && !libraryHas(set, diff, sym)
+}
+
+// nextRefill picks the difficulty that needs puzzles with the given
+// symmetry, starting with the one being played and then the tiers on
+// either side of it, and passing over tiers the board does not offer and
+// unreachable keys. Only the size and variant being played are refilled.
+func (pool puzzlePool) nextRefill(current puzzleSet, currentDiff difficulty, sym engine.Symmetry, unreachable map[string]bool) (difficulty, bool) {
+	for _, diff := range []difficulty{currentDiff, currentDiff + 1, currentDiff - 1} {
+		if !current.offers(diff) {
+			continue
+		}
+		if !unreachable[unreachableKey(current, diff, sym)] && pool.needs(current, diff, sym) {
+			return diff, true
+		}
+	}
+	return diffEasy, false
+}
+
+// sortedSizes lists the supported sizes in ascending order.
+func sortedSizes() []int {
+	sizes := make([]int, 0, len(puzzleSets))
+	for size := range puzzleSets {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+	return sizes
+}
@@ -1,105 +1,221 @@
 package sudoku
 
-import "strings"
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
-// puzzle stores a Sudoku puzzle grid and its full solution.
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
+)
+
+// puzzle stores a Sudoku puzzle grid, its full solution, its rated
+// difficulty and score, the symmetry its givens were carved with, the ID
+// that reproduces it, and, for variants, the rules it was made for.
 type puzzle struct {
-	puzzle   []uint8
-	solution []uint8
+	id         string
+	puzzle     []uint8
+	solution   []uint8
+	difficulty difficulty
+	score      float64
+	symmetry   engine.Symmetry
+	rules      *engine.Rules
 }
 
-// puzzleSet defines a board size and its box dimensions.
+// puzzleSet defines a board size, its box dimensions, and the variant
+// played on it.
 type puzzleSet struct {
 	size    int
 	boxRows int
 	boxCols int
+	variant string
+}
+
+// geometry converts the set into the engine's geometry type.
+func (s puzzleSet) geometry() engine.Geometry {
+	return engine.Geometry{Size: s.size, BoxRows: s.boxRows, BoxCols: s.boxCols}
+}
+
+// blocks returns the rows and columns of the blocks the board is drawn
+// in: the boxes, or the whole board on Jigsaw sets, whose irregular boxes
+// are outlined instead.
+func (s puzzleSet) blocks() (int, int) {
+	if s.variant == "jigsaw" {
+		return s.size, s.size
+	}
+	return s.boxRows, s.boxCols
+}
+
+// rules returns the standard engine rules for the set's geometry, or the
+// engine's error for a geometry it rejects. Every catalog set has rules;
+// TestCatalogRules keeps it that way.
+func (s puzzleSet) rules() (*engine.Rules, error) {
+	r, err := engine.StandardRules(s.geometry())
+	if err != nil {
+		return nil, fmt.Errorf("%dx%d board: %w", s.size, s.size, err)
+	}
+	return r, nil
 }
 
 // difficulty represents a requested puzzle difficulty level.
-type difficulty int
+type difficulty = engine.Difficulty
 
-const (
-	diffEasy difficulty = iota
-	diffMedium
-	diffHard
-)
+const diffEasy = engine.Easy
 
 // puzzleSets is the size catalog used across the app.
 var puzzleSets = map[int]puzzleSet{
-	4: {size: 4, boxRows: 2, boxCols: 2},
-	6: {size: 6, boxRows: 2, boxCols: 3},
-	9: {size: 9, boxRows: 3, boxCols: 3},
+	4:  {size: 4, boxRows: 2, boxCols: 2},
+	6:  {size: 6, boxRows: 2, boxCols: 3},
+	8:  {size: 8, boxRows: 2, boxCols: 4},
+	9:  {size: 9, boxRows: 3, boxCols: 3},
+	10: {size: 10, boxRows: 2, boxCols: 5},
+	12: {size: 12, boxRows: 3, boxCols: 4},
+	16: {size: 16, boxRows: 4, boxCols: 4},
+}
+
+// matchSize reports the board size typed so far, once no larger size
+// starts with the same digits.
+func matchSize(input string) (int, bool) {
+	for _, size := range sortedSizes() {
+		label := strconv.Itoa(size)
+		if label != input && strings.HasPrefix(label, input) {
+			return 0, false
+		}
+	}
+	size, err := strconv.Atoi(input)
+	if err != nil {
+		return 0, false
+	}
+	_, ok := puzzleSets[size]
+	return size, ok
+}
+
+// sizePrefix reports whether input starts the label of some board size.
+func sizePrefix(input string) bool {
+	for size := range puzzleSets {
+		if strings.HasPrefix(strconv.Itoa(size), input) {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeChoices lists the board sizes for prompts, such as "4, 6, 9 or 16".
+func sizeChoices() string {
+	sizes := sortedSizes()
+	labels := make([]string, len(sizes))
+	for i, size := range sizes {
+		labels[i] = strconv.Itoa(size)
+	}
+	last := len(labels) - 1
+	return strings.Join(labels[:last], ", ") + " or " + labels[last]
 }
 
 // difficultyLabel returns the display label for a difficulty value.
 func difficultyLabel(d difficulty) string {
-	switch d {
-	case diffEasy:
-		return "Easy"
-	case diffMedium:
-		return "Medium"
-	case diffHard:
-		return "Hard"
-	default:
-		return "Easy"
-	}
+	return d.String()
 }
 
-// parseDifficulty converts a string into a difficulty value.
+// parseDifficulty converts a string into a difficulty value, defaulting to Easy.
 func parseDifficulty(value string) difficulty {
-	switch strings.ToLower(value) {
-	case "easy":
-		return diffEasy
-	case "medium":
-		return diffMedium
-	case "hard":
-		return diffHard
-	default:
+	d, err := engine.ParseDifficulty(value)
+	if err != nil {
 		return diffEasy
 	}
+	return d
 }
 
-// nextDifficulty cycles difficulty values.
-func nextDifficulty(d difficulty) difficulty {
-	switch d {
-	case diffEasy:
-		return diffMedium
-	case diffMedium:
-		return diffHard
-	default:
-		return diffEasy
+// parseSymmetry converts a string into a symmetry, defaulting to none.
+func parseSymmetry(value string) engine.Symmetry {
+	s, err := engine.ParseSymmetry(value)
+	if err != nil {
+		return engine.SymmetryNone
 	}
+	return s
 }
 
-// clueCount returns target clue counts per size/difficulty.
-func clueCount(size int, diff difficulty) int {
-	switch size {
-	case 4:
-		switch diff {
-		case diffEasy:
-			return 10
-		case diffMedium:
-			return 8
-		default:
-			return 6
+// nextSymmetry cycles symmetry modes.
+func nextSymmetry(s engine.Symmetry) engine.Symmetry {
+	for i, mode := range engine.Symmetries {
+		if mode == s {
+			return engine.Symmetries[(i+1)%len(engine.Symmetries)]
 		}
-	case 6:
-		switch diff {
-		case diffEasy:
-			return 20
-		case diffMedium:
-			return 16
-		default:
-			return 12
+	}
+	return engine.SymmetryNone
+}
+
+// sizeTiers lists the difficulties offered per board size: the tiers the
+// generator reached on every try within generateTimeout. 4x4 puzzles
+// never need more than singles, and 12x12 and 16x16 boards seldom carve
+// the middle tiers in time.
+var sizeTiers = map[int][]difficulty{
+	4:  {engine.Easy},
+	6:  {engine.Easy, engine.Medium, engine.Hard, engine.Expert, engine.Evil},
+	8:  engine.Difficulties,
+	9:  engine.Difficulties,
+	10: engine.Difficulties,
+	12: {engine.Easy, engine.Medium, engine.Evil, engine.Diabolical},
+	16: {engine.Easy, engine.Medium},
+}
+
+// variantTiers overrides sizeTiers for variants whose puzzles reach other
+// tiers than classic ones, measured the same way. Killer and Kropki
+// puzzles mostly rate Medium or Diabolical, and on 10x10 and 16x16 boards
+// few Killer attempts finish in time at all.
+var variantTiers = map[string]map[int][]difficulty{
+	"killer": {
+		6:  {engine.Easy, engine.Medium, engine.Hard, engine.Evil},
+		8:  {engine.Easy, engine.Medium, engine.Diabolical},
+		9:  {engine.Easy, engine.Medium, engine.Diabolical},
+		10: {engine.Easy},
+		12: {engine.Easy, engine.Medium},
+		16: {engine.Easy},
+	},
+	"kropki": {
+		6:  {engine.Easy, engine.Medium, engine.Hard, engine.Expert, engine.Evil, engine.Diabolical},
+		8:  {engine.Easy, engine.Medium, engine.Diabolical},
+		9:  {engine.Easy, engine.Medium, engine.Diabolical},
+		10: {engine.Easy, engine.Medium, engine.Diabolical},
+		12: {engine.Easy, engine.Medium},
+		16: {engine.Easy},
+	},
+}
+
+// tiers returns the difficulties offered for the set's variant and board.
+func (s puzzleSet) tiers() []difficulty {
+	if tiers, ok := variantTiers[s.variant][s.size]; ok {
+		return tiers
+	}
+	if tiers, ok := sizeTiers[s.size]; ok {
+		return tiers
+	}
+	return []difficulty{diffEasy}
+}
+
+// offers reports whether the set's board offers a difficulty.
+func (s puzzleSet) offers(d difficulty) bool {
+	return slices.Contains(s.tiers(), d)
+}
+
+// tier returns d when the set offers it, and otherwise the hardest
+// offered tier below it, or the easiest one.
+func (s puzzleSet) tier(d difficulty) difficulty {
+	tiers := s.tiers()
+	best := tiers[0]
+	for _, t := range tiers {
+		if t <= d {
+			best = t
 		}
-	default:
-		switch diff {
-		case diffEasy:
-			return 36
-		case diffMedium:
-			return 30
-		default:
-			return 24
+	}
+	return best
+}
+
+// nextDifficulty cycles through the tiers the set offers.
+func (s puzzleSet) nextDifficulty(d difficulty) difficulty {
+	for _, t := range s.tiers() {
+		if t > d {
+			return t
 		}
 	}
+	return s.tiers()[0]
 }
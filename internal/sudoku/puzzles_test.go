@@ -0,0 +1,76 @@
+package sudoku
+
+import (
+	"testing"
+
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
+)
+
+// TestCatalogRules checks that every catalog set has rules and offers
+// Easy, so loading a puzzle on it cannot fail for lack of either.
+func TestCatalogRules(t *testing.T) {
+	for size, set := range puzzleSets {
+		if set.size != size {
+			t.Errorf("set %d has size %d", size, set.size)
+		}
+		if _, err := set.rules(); err != nil {
+			t.Errorf("set %d: %v", size, err)
+		}
+		if _, ok := sizeTiers[size]; !ok {
+			t.Errorf("set %d offers no tiers", size)
+		}
+		if !set.offers(diffEasy) {
+			t.Errorf("set %d does not offer Easy", size)
+		}
+	}
+	if _, err := (puzzleSet{size: 9, boxRows: 2, boxCols: 4}).rules(); err == nil {
+		t.Error("rules for 2x4 boxes on a 9x9 board")
+	}
+}
+
+// TestTiers checks the difficulty a set falls back to and the cycle
+// through the tiers it offers.
+func TestTiers(t *testing.T) {
+	cases := []struct {
+		size       int
+		d          difficulty
+		tier, next difficulty
+	}{
+		{4, engine.Hard, engine.Easy, engine.Easy},
+		{6, engine.Diabolical, engine.Evil, engine.Easy},
+		{9, engine.Expert, engine.Expert, engine.Evil},
+		{12, engine.Hard, engine.Medium, engine.Evil},
+		{12, engine.Diabolical, engine.Diabolical, engine.Easy},
+		{16, engine.Evil, engine.Medium, engine.Easy},
+		{16, engine.Easy, engine.Easy, engine.Medium},
+	}
+	for _, c := range cases {
+		set := puzzleSets[c.size]
+		if got := set.tier(c.d); got != c.tier {
+			t.Errorf("%dx%d tier(%s) = %s, want %s", c.size, c.size, c.d, got, c.tier)
+		}
+		if got := set.nextDifficulty(c.d); got != c.next {
+			t.Errorf("%dx%d nextDifficulty(%s) = %s, want %s", c.size, c.size, c.d, got, c.next)
+		}
+	}
+}
+
+// TestVariantTiers checks that a variant's own tiers replace the size's.
+func TestVariantTiers(t *testing.T) {
+	killer := puzzleSets[9].withVariant("killer")
+	if got := killer.tier(engine.Evil); got != engine.Medium {
+		t.Errorf("9x9 Killer tier(Evil) = %s, want Medium", got)
+	}
+	if got := killer.nextDifficulty(engine.Medium); got != engine.Diabolical {
+		t.Errorf("9x9 Killer nextDifficulty(Medium) = %s, want Diabolical", got)
+	}
+	if got := puzzleSets[9].withVariant("kropki").tier(engine.Hard); got != engine.Medium {
+		t.Errorf("9x9 Kropki tier(Hard) = %s, want Medium", got)
+	}
+	if !puzzleSets[6].withVariant("kropki").offers(engine.Diabolical) || puzzleSets[6].offers(engine.Diabolical) {
+		t.Error("6x6 Kropki should offer Diabolical where classic 6x6 does not")
+	}
+	if got := puzzleSets[4].withVariant("killer").tiers(); len(got) != 1 || got[0] != engine.Easy {
+		t.Errorf("4x4 Killer tiers %v, want Easy alone", got)
+	}
+}
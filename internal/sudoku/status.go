@@ -10,10 +10,15 @@ import (
 
 // statusView renders the status panel or selection prompts.
 func (m model) statusView() string {
-	width := boardFrameWidth(m.set.size, m.set.boxCols)
+	_, blockCols := m.set.blocks()
+	width := boardFrameWidth(m.set.size, blockCols)
 	if m.selectingSize {
 		title := statusTitleStyle.Render("Select size")
-		body := statusTextStyle.Render("Press 4, 6, or 9 (Esc to cancel)")
+		prompt := "Press " + sizeChoices() + " (Esc to cancel)"
+		if m.sizeInput != "" {
+			prompt = "Size " + m.sizeInput + "… " + prompt
+		}
+		body := statusTextStyle.Render(prompt)
 		return statusBoxStyle.Width(width).Render(title + "\n" + body)
 	}
 	if m.selectingSlot {
@@ -25,9 +30,23 @@ func (m model) statusView() string {
 		body := statusTextStyle.Render(fmt.Sprintf("Press 1-%d to %s (Esc to cancel)", slotCount, mode))
 		return statusBoxStyle.Width(width).Render(title + "\n" + body)
 	}
+	if m.entering {
+		title := statusTitleStyle.Render("Enter value")
+		body := statusTextStyle.Render(fmt.Sprintf("Value %s: %s_ (Enter to confirm, Esc to cancel)", m.glyphs.span(m.set.size), m.entryInput))
+		return statusBoxStyle.Width(width).Render(title + "\n" + body)
+	}
+	if m.generating {
+		label := fmt.Sprintf("%dx%d %s", m.genSet.size, m.genSet.size, difficultyLabel(m.genDiff))
+		if m.genSet.variant != variantClassic {
+			label += " " + variantLabel(m.genSet.variant)
+		}
+		title := statusAccentStyle.Render(spinnerFrames[m.spinner] + " Generating " + label + "…")
+		body := statusTextStyle.Render("Keep playing; the new puzzle loads when ready")
+		return statusBoxStyle.Width(width).Render(title + "\n" + body)
+	}
 	if m.gameOver {
 		title := statusDangerStyle.Render("Game over")
-		body := statusTextStyle.Render("n new | r reset | d difficulty | s size | o load | q quit")
+		body := statusTextStyle.Render("n new | r reset | d difficulty | s size | V variant | o load | q quit")
 		return statusBoxStyle.Width(width).Render(title + "\n" + body)
 	}
 	if m.solved {
@@ -39,15 +58,23 @@ func (m model) statusView() string {
 		msg := statusAccentStyle.Render(m.flashMessage)
 		return statusBoxStyle.Width(width).Render(msg)
 	}
-	best := bestTimeString(m.stats, m.set.size, m.difficulty)
+	best := bestTimeString(m.stats, m.set, m.difficulty)
 	statsLine := fmt.Sprintf("Mistakes %d/%d  Hints %d  Best %s  Slot %d", m.mistakes, maxMistakes, m.hintsUsed, best, m.activeSlot)
 	statsLine = statusTextStyle.Render(statsLine)
 	controlsLine := fmt.Sprintf(
-		"Keys: 1-%d set  p notes  v validate  u/y undo  H hint  m strict  s size  d diff  w save  o load  ? help  q quit",
-		m.set.size,
+		"Keys: %s set  %s type value  p notes  v validate  u/y undo  H hint  m strict  s size  d diff  S sym  V variant  g glyphs  w save  o load  ? help  q quit",
+		m.glyphs.span(m.set.size),
+		entryPrefix,
 	)
 	controlsLine = statusHintStyle.Render(controlsLine)
 	lines := []string{statsLine, controlsLine}
+	if m.requested != m.difficulty {
+		notice := fmt.Sprintf("No %s puzzle was found in time; this one rates %s.", difficultyLabel(m.requested), difficultyLabel(m.difficulty))
+		lines = append(lines, statusDangerStyle.Render(notice))
+	}
+	if m.hintNote != "" {
+		lines = append(lines, statusInfoStyle.Render(m.hintNote))
+	}
 	return statusBoxStyle.Width(width).Render(strings.Join(lines, "\n"))
 }
 
@@ -72,14 +99,9 @@ func onOff(value bool) string {
 	return "OFF"
 }
 
-// statsKey returns a key used for best-time storage.
-func statsKey(size int, diff difficulty) string {
-	return fmt.Sprintf("%dx%d:%s", size, size, strings.ToLower(difficultyLabel(diff)))
-}
-
-// bestTimeString returns the formatted best time for size/difficulty.
-func bestTimeString(st stats, size int, diff difficulty) string {
-	key := statsKey(size, diff)
+// bestTimeString returns the formatted best time for a set/difficulty.
+func bestTimeString(st stats, set puzzleSet, diff difficulty) string {
+	key := libraryKey(set, diff)
 	best, ok := st.Best[key]
 	if !ok || best <= 0 {
 		return "--:--"
@@ -12,12 +12,25 @@ var (
 	bgSelected      = lipgloss.Color("#2F5D62")
 	bgSelectedPulse = lipgloss.Color("#387175")
 	bgConflict      = lipgloss.Color("#6B2F2F")
+	bgDiagonal1     = lipgloss.Color("#2C2838")
+	bgDiagonal2     = lipgloss.Color("#26222F")
+	bgWindow1       = lipgloss.Color("#2E3326")
+	bgWindow2       = lipgloss.Color("#282C21")
+	bgPath1         = lipgloss.Color("#2A2D36")
+	bgPath2         = lipgloss.Color("#24272F")
+	bgBulb1         = lipgloss.Color("#3A3340")
+	bgBulb2         = lipgloss.Color("#332D39")
 
 	fgFixed    = lipgloss.Color("#F2CC8F")
 	fgFilled   = lipgloss.Color("#F4F1DE")
 	fgMuted    = lipgloss.Color("#6C7A89")
 	fgContrast = lipgloss.Color("#FFFFFF")
 	fgNote     = lipgloss.Color("#8A9AA6")
+	fgCage     = lipgloss.Color("#8C7A5B")
+	fgCageSum  = lipgloss.Color("#9AD1D4")
+	fgBox      = lipgloss.Color("#E9C46A")
+	fgPath     = lipgloss.Color("#7D8597")
+	fgDot      = lipgloss.Color("#E0E1DD")
 
 	headerBarStyle = lipgloss.NewStyle().
 			Bold(true).
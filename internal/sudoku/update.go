@@ -7,9 +7,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// Init starts the periodic tick for UI updates.
+// Init starts the periodic tick for UI updates and the pool refill.
 func (m model) Init() tea.Cmd {
-	return tickCmd()
+	return tea.Batch(tickCmd(), func() tea.Msg { return refillMsg{} })
 }
 
 // tickCmd emits a tick every second.
@@ -29,6 +29,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		m.pulse = !m.pulse
 		return m, tickCmd()
+	case spinnerMsg:
+		if !m.generating {
+			return m, nil
+		}
+		m.spinner = (m.spinner + 1) % len(spinnerFrames)
+		return m, spinnerCmd()
+	case puzzleMsg:
+		return m, m.handlePuzzle(msg)
+	case poolMsg:
+		return m, m.handlePool(msg)
+	case refillMsg:
+		return m, m.refill()
+	case hintMsg:
+		m.handleHint(msg)
+		return m, nil
 	case tea.KeyMsg:
 		if m.selectingSlot {
 			switch msg.String() {
@@ -49,8 +64,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					} else {
 						if saved, ok := loadSlot(slot); ok {
-							m = modelFromSave(saved, m.stats, slot)
-							m.flash(fmt.Sprintf("Loaded slot %d", slot))
+							if loaded, err := m.withSave(saved, slot); err == nil {
+								m = loaded
+								m.flash(fmt.Sprintf("Loaded slot %d", slot))
+							} else {
+								m.flash(fmt.Sprintf("Cannot load slot %d", slot))
+							}
 						} else {
 							m.flash("Empty slot")
 						}
@@ -81,12 +100,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			case "esc", "s":
 				m.selectingSize = false
-				return m, nil
-			case "4", "6", "9":
-				m.setSize(int(msg.Runes[0] - '0'))
-				m.selectingSize = false
+				m.sizeInput = ""
 				return m, nil
 			default:
+				if len(msg.Runes) != 1 || msg.Runes[0] < '0' || msg.Runes[0] > '9' {
+					return m, nil
+				}
+				input := m.sizeInput + string(msg.Runes)
+				size, ok := matchSize(input)
+				switch {
+				case ok:
+					m.selectingSize = false
+					m.sizeInput = ""
+					return m, m.setSize(size)
+				case sizePrefix(input):
+					m.sizeInput = input
+				default:
+					m.sizeInput = ""
+				}
 				return m, nil
 			}
 		}
@@ -96,8 +127,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "ctrl+c", "q":
 				return m, tea.Quit
 			case "n":
-				m.newPuzzle()
-				return m, nil
+				return m, m.newPuzzle()
 			case "r":
 				m.reset()
 				return m, nil
@@ -105,8 +135,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.selectingSize = true
 				return m, nil
 			case "d":
-				m.setDifficulty(nextDifficulty(m.difficulty))
-				return m, nil
+				return m, m.setDifficulty(m.set.nextDifficulty(m.requested))
+			case "V":
+				return m, m.setVariant(nextVariant(m.set))
 			case "o":
 				m.slotMode = slotLoad
 				m.selectingSlot = true
@@ -118,18 +149,47 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.entering {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc":
+				m.cancelEntry()
+			case "enter":
+				m.confirmEntry()
+			case "backspace":
+				if m.entryInput == "" {
+					m.cancelEntry()
+				} else {
+					m.entryInput = m.entryInput[:len(m.entryInput)-1]
+				}
+			default:
+				if len(msg.Runes) == 1 {
+					m.typeEntry(string(msg.Runes))
+				}
+			}
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		case "n":
-			m.newPuzzle()
-			return m, nil
+			return m, m.newPuzzle()
 		case "r":
 			m.reset()
 			return m, nil
 		case "s":
 			m.selectingSize = true
 			return m, nil
+		case entryPrefix:
+			m.entering = true
+			return m, nil
+		case "g":
+			m.glyphs = nextGlyphSet(m.glyphs)
+			m.flash("Glyphs: " + m.glyphs.String())
+			m.autoSave()
+			return m, nil
 		case "m":
 			m.strictMode = !m.strictMode
 			if m.strictMode {
@@ -139,8 +199,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		case "d":
-			m.setDifficulty(nextDifficulty(m.difficulty))
-			return m, nil
+			return m, m.setDifficulty(m.set.nextDifficulty(m.requested))
+		case "S":
+			return m, m.setSymmetry(nextSymmetry(m.symmetry))
+		case "V":
+			return m, m.setVariant(nextVariant(m.set))
 		case "p":
 			m.noteMode = !m.noteMode
 			if m.noteMode {
@@ -201,19 +264,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			if r == 'H' {
-				m.applyHint()
-				return m, nil
+				return m, m.applyHint()
 			}
-			if r >= '1' && r <= '9' {
-				value := int(r - '0')
-				if value <= m.set.size {
-					if m.noteMode {
-						m.toggleNote(m.row, m.col, value)
-					} else {
-						m.setValue(uint8(value))
-					}
-					return m, nil
-				}
+			if value, ok := m.glyphs.directValue(string(r), m.set.size); ok {
+				m.enterValue(value)
+				return m, nil
 			}
 		}
 	}
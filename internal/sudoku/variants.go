@@ -0,0 +1,292 @@
+package sudoku
+
+import (
+	"strings"
+
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
+)
+
+// variantClassic is the name of plain Sudoku; other variants use the
+// engine's names.
+const variantClassic = ""
+
+// variantRules describes what each variant adds to the classic rules.
+var variantRules = map[string]string{
+	"arrow":    "Digits along each arrow add up to its circle",
+	"disjoint": "Cells in the same spot of every box also differ",
+	"jigsaw":   "Boxes take irregular shapes, outlined in bold",
+	"killer":   "Cages hold distinct digits adding up to their sum",
+	"kropki":   "○ joins consecutive digits, ● a digit and its double",
+	"thermo":   "Digits climb along each thermometer from its bulb",
+	"windoku":  "The shaded windows also hold each value once",
+	"x":        "Both shaded diagonals also hold each value once",
+}
+
+// variantNames overrides the display name of variants whose engine name
+// reads poorly.
+var variantNames = map[string]string{
+	"disjoint": "Disjoint Groups",
+	"x":        "X-Sudoku",
+}
+
+// gameVariants lists the variants the game deals, classic first.
+func gameVariants() []string {
+	return append([]string{variantClassic}, engine.VariantNames()...)
+}
+
+// variantLabel returns the display name of a variant.
+func variantLabel(name string) string {
+	if name == variantClassic {
+		return "Classic"
+	}
+	if label, ok := variantNames[name]; ok {
+		return label
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// variantLabels lists the display names of every variant.
+func variantLabels() []string {
+	list := gameVariants()
+	labels := make([]string, len(list))
+	for i, v := range list {
+		labels[i] = variantLabel(v)
+	}
+	return labels
+}
+
+// nextVariant cycles through the variants played on a set's board size.
+func nextVariant(set puzzleSet) string {
+	list := gameVariants()
+	for i, v := range list {
+		if v != set.variant {
+			continue
+		}
+		for _, next := range append(list[i+1:], list[:i]...) {
+			if set.withVariant(next).fits() {
+				return next
+			}
+		}
+	}
+	return variantClassic
+}
+
+// withVariant returns the set for another variant.
+func (s puzzleSet) withVariant(name string) puzzleSet {
+	s.variant = name
+	return s
+}
+
+// fits reports whether the set's variant is played on its board size,
+// as Windoku is only on smaller boards.
+func (s puzzleSet) fits() bool {
+	v := s.engineVariant()
+	if v == nil {
+		return true
+	}
+	_, err := v.Base(s.geometry(), nil)
+	return err == nil
+}
+
+// engineVariant returns the engine variant the set generates, or nil for
+// classic puzzles.
+func (s puzzleSet) engineVariant() engine.Variant {
+	v, err := engine.VariantByName(s.variant)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// variantData is the JSON form of what a variant puzzle's rules add to
+// its variant's name: Killer cages, a Jigsaw box map giving the box of
+// each cell, Thermo paths listing each thermometer's cells from the
+// bulb, Kropki dots, with Negative set when every dot is given, or
+// Arrow arrows.
+type variantData struct {
+	Cages    []cageEntry  `json:"cages,omitempty"`
+	Boxes    []int        `json:"boxes,omitempty"`
+	Thermos  [][]int      `json:"thermos,omitempty"`
+	Dots     []dotEntry   `json:"dots,omitempty"`
+	Negative bool         `json:"negative,omitempty"`
+	Arrows   []arrowEntry `json:"arrows,omitempty"`
+}
+
+// cageEntry is the JSON form of a Killer cage.
+type cageEntry struct {
+	Cells []int `json:"cells"`
+	Sum   int   `json:"sum"`
+}
+
+// dotEntry is the JSON form of a Kropki dot; Kind is "white" or "black".
+type dotEntry struct {
+	Cells [2]int `json:"cells"`
+	Kind  string `json:"kind"`
+}
+
+// arrowEntry is the JSON form of an arrow: its circle and the cells of
+// its shaft from the circle to the tip.
+type arrowEntry struct {
+	Circle int   `json:"circle"`
+	Cells  []int `json:"cells"`
+}
+
+// dotKinds maps the JSON names of dot kinds to the engine's.
+var dotKinds = map[string]engine.DotKind{
+	engine.WhiteDot.String(): engine.WhiteDot,
+	engine.BlackDot.String(): engine.BlackDot,
+}
+
+// variantDataOf records what a puzzle's rules add for its variant.
+func variantDataOf(set puzzleSet, rules *engine.Rules) variantData {
+	var data variantData
+	if k := killerOf(rules); k != nil {
+		for _, cage := range k.Cages() {
+			data.Cages = append(data.Cages, cageEntry{Cells: cage.Cells, Sum: cage.Sum})
+		}
+	}
+	if set.variant == "jigsaw" && rules != nil {
+		data.Boxes = rules.BoxMap()
+	}
+	if t := thermosOf(rules); t != nil {
+		data.Thermos = t.Paths()
+	}
+	if k := kropkiOf(rules); k != nil {
+		for _, dot := range k.Dots() {
+			data.Dots = append(data.Dots, dotEntry{Cells: dot.Cells, Kind: dot.Kind.String()})
+		}
+		data.Negative = k.Negative()
+	}
+	if a := arrowsOf(rules); a != nil {
+		for _, arrow := range a.Arrows() {
+			data.Arrows = append(data.Arrows, arrowEntry{Circle: arrow.Circle, Cells: arrow.Cells})
+		}
+	}
+	return data
+}
+
+// variantRulesFor rebuilds the rules of a saved variant puzzle from its
+// set and variant data. It returns nil for classic sets and false when the
+// saved data does not describe a valid puzzle of the variant. Variants
+// with fixed rules, such as X-Sudoku or Windoku, need no saved data.
+func variantRulesFor(set puzzleSet, data variantData) (*engine.Rules, bool) {
+	constraints := engine.StandardConstraints()
+	switch set.variant {
+	case variantClassic:
+		return nil, true
+	case "x", "windoku", "disjoint":
+		rules, err := set.engineVariant().Base(set.geometry(), nil)
+		return rules, err == nil
+	case "jigsaw":
+		boxes, err := engine.JigsawBoxes(set.geometry(), data.Boxes)
+		if err != nil {
+			return nil, false
+		}
+		constraints = []engine.Constraint{engine.Rows, engine.Columns, boxes}
+	case "killer":
+		if len(data.Cages) == 0 {
+			return nil, false
+		}
+		list := make([]engine.Cage, len(data.Cages))
+		for i, c := range data.Cages {
+			list[i] = engine.Cage{Cells: c.Cells, Sum: c.Sum}
+		}
+		k, err := engine.NewKiller(set.geometry(), list)
+		if err != nil {
+			return nil, false
+		}
+		constraints = append(constraints, k)
+	case "thermo":
+		if len(data.Thermos) == 0 {
+			return nil, false
+		}
+		t, err := engine.NewThermometers(set.geometry(), data.Thermos)
+		if err != nil {
+			return nil, false
+		}
+		constraints = append(constraints, t)
+	case "kropki":
+		dots := make([]engine.Dot, len(data.Dots))
+		for i, d := range data.Dots {
+			kind, ok := dotKinds[d.Kind]
+			if !ok {
+				return nil, false
+			}
+			dots[i] = engine.Dot{Cells: d.Cells, Kind: kind}
+		}
+		k, err := engine.NewKropki(set.geometry(), dots, data.Negative)
+		if err != nil {
+			return nil, false
+		}
+		constraints = append(constraints, k)
+	case "arrow":
+		if len(data.Arrows) == 0 {
+			return nil, false
+		}
+		list := make([]engine.Arrow, len(data.Arrows))
+		for i, a := range data.Arrows {
+			list[i] = engine.Arrow{Circle: a.Circle, Cells: a.Cells}
+		}
+		a, err := engine.NewArrows(set.geometry(), list)
+		if err != nil {
+			return nil, false
+		}
+		constraints = append(constraints, a)
+	default:
+		return nil, false
+	}
+	rules, err := engine.NewRules(set.geometry(), constraints...)
+	return rules, err == nil
+}
+
+// killerOf returns the cage rule of a puzzle's rules, if any.
+func killerOf(rules *engine.Rules) *engine.Killer {
+	if rules == nil {
+		return nil
+	}
+	for _, c := range rules.Constraints() {
+		if k, ok := c.(*engine.Killer); ok {
+			return k
+		}
+	}
+	return nil
+}
+
+// thermosOf returns the thermometer rule of a puzzle's rules, if any.
+func thermosOf(rules *engine.Rules) *engine.Thermometers {
+	if rules == nil {
+		return nil
+	}
+	for _, c := range rules.Constraints() {
+		if t, ok := c.(*engine.Thermometers); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// kropkiOf returns the dot rule of a puzzle's rules, if any.
+func kropkiOf(rules *engine.Rules) *engine.Kropki {
+	if rules == nil {
+		return nil
+	}
+	for _, c := range rules.Constraints() {
+		if k, ok := c.(*engine.Kropki); ok {
+			return k
+		}
+	}
+	return nil
+}
+
+// arrowsOf returns the arrow rule of a puzzle's rules, if any.
+func arrowsOf(rules *engine.Rules) *engine.Arrows {
+	if rules == nil {
+		return nil
+	}
+	for _, c := range rules.Constraints() {
+		if a, ok := c.(*engine.Arrows); ok {
+			return a
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	engine "github.com/hacktails/mini-sudoku-go/pkg/sudoku"
 )
 
 // View renders the entire game screen.
@@ -37,7 +38,8 @@ func (m model) View() string {
 
 // headerView renders the title, subtitle, and meta badges.
 func (m model) headerView(timeStr string) string {
-	width := boardFrameWidth(m.set.size, m.set.boxCols)
+	_, blockCols := m.set.blocks()
+	width := boardFrameWidth(m.set.size, blockCols)
 	line := alignLeftRight(
 		"Mini Sudoku",
 		timeStr,
@@ -45,12 +47,24 @@ func (m model) headerView(timeStr string) string {
 	)
 	bar := headerBarStyle.Render(line)
 
+	box := fmt.Sprintf("%dx%d box", m.set.boxRows, m.set.boxCols)
+	if m.set.variant == "jigsaw" {
+		box = "outlined box"
+	}
 	subtitle := fmt.Sprintf(
-		"Fill each row, column, and %dx%d box with 1-%d",
-		m.set.boxRows,
-		m.set.boxCols,
-		m.set.size,
+		"Fill each row, column, and %s with %s",
+		box,
+		m.glyphs.span(m.set.size),
 	)
+	if rule, ok := variantRules[m.set.variant]; ok {
+		subtitle += "\n" + rule
+	}
+	if k := kropkiOf(m.puzzle.rules); k != nil && k.Negative() {
+		subtitle += "; no dot means neither"
+	}
+	if m.puzzle.id != "" {
+		subtitle += "\nID " + m.puzzle.id
+	}
 	info := subtitleStyle.Width(width).Align(lipgloss.Center).Render(subtitle)
 	meta := metaStyle.Width(width).Align(lipgloss.Center).Render(m.metaView())
 
@@ -68,8 +82,22 @@ func alignLeftRight(left, right string, width int) string {
 
 // metaView renders the header badges.
 func (m model) metaView() string {
-	sizeBadge := badge(fmt.Sprintf("Size %dx%d", m.set.size, m.set.size), badgeAccentStyle)
-	diffBadge := badge("Diff "+strings.ToUpper(difficultyLabel(m.difficulty)), badgePrimaryStyle)
+	sizeLabel := fmt.Sprintf("Size %dx%d", m.set.size, m.set.size)
+	if m.set.variant != variantClassic {
+		sizeLabel += " " + strings.ToUpper(variantLabel(m.set.variant))
+	}
+	sizeBadge := badge(sizeLabel, badgeAccentStyle)
+	diffLabel := "Diff " + strings.ToUpper(difficultyLabel(m.difficulty))
+	if m.puzzle.score > 0 {
+		diffLabel += fmt.Sprintf(" %.1f", m.puzzle.score)
+	}
+	if m.requested != m.difficulty {
+		diffLabel += " (ASKED " + strings.ToUpper(difficultyLabel(m.requested)) + ")"
+	}
+	if m.puzzle.symmetry != engine.SymmetryNone {
+		diffLabel += " · " + strings.ToUpper(m.puzzle.symmetry.String())
+	}
+	diffBadge := badge(diffLabel, badgePrimaryStyle)
 	notesBadge := toggleBadge("Notes", m.noteMode, badgeOnStyle, badgeOffStyle)
 	validateBadge := toggleBadge("Validate", m.showConflicts, badgeOnStyle, badgeOffStyle)
 	strictBadge := toggleBadge("Strict", m.strictMode, badgeWarnStyle, badgeOffStyle)
@@ -91,14 +119,17 @@ func (m model) metaView() string {
 func (m model) helpView() string {
 	lines := []string{
 		"Navigation: arrows or hjkl",
-		"Numbers: 1-9 set value (respecting size)",
+		"Values: type the glyph (respecting size); + then the full label for the rest",
+		"Glyphs: g (Numbers, 1-9 A-G, Hex 0-F, Letters)",
 		"Notes: p toggle notes, c clear notes in cell",
 		"Undo/Redo: u / y",
 		"Hint: H (smart hint first, then reveal)",
 		"Validate: v",
 		"Strict mode: m",
-		"Size: s then 4/6/9",
-		"Difficulty: d",
+		"Size: s then 4/6/8/9/10/12/16",
+		"Difficulty: d (Easy, Medium, Hard, Expert, Evil, Diabolical)",
+		"Clue symmetry: S (None, 180/90 rotation, mirror H/V, diagonal)",
+		"Variant: V (" + strings.Join(variantLabels(), ", ") + ")",
 		"Save/Load slots: w / o, then 1-3",
 		"Quit: q",
 	}
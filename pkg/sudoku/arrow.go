@@ -0,0 +1,294 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Arrow is an Arrow Sudoku arrow: the digits along its shaft, Cells,
+// add up to the digit in its Circle.
+type Arrow struct {
+	Circle int
+	Cells  []int
+}
+
+// Arrows is the rule of Arrow Sudoku. Build it with NewArrows.
+type Arrows struct {
+	arrows  []Arrow
+	arrowOf [][]int
+}
+
+// NewArrows checks arrows against a geometry: the circle and every shaft
+// cell on the board, a shaft of one to Size-1 cells that starts next to
+// the circle and moves to an orthogonal neighbour at each step, and no
+// cell visited twice. Separate arrows may share cells.
+func NewArrows(g Geometry, arrows []Arrow) (*Arrows, error) {
+	a := &Arrows{arrows: arrows, arrowOf: make([][]int, g.Cells())}
+	for n, arrow := range arrows {
+		if len(arrow.Cells) == 0 || len(arrow.Cells) >= g.Size {
+			return nil, fmt.Errorf("%w: arrow %d has %d cells", ErrInvalidRules, n+1, len(arrow.Cells))
+		}
+		path := append([]int{arrow.Circle}, arrow.Cells...)
+		for p, i := range path {
+			if i < 0 || i >= g.Cells() || containsInt(path[:p], i) {
+				return nil, fmt.Errorf("%w: arrow %d reuses or misplaces cell %d", ErrInvalidRules, n+1, i)
+			}
+			if p > 0 && !containsInt(neighbours(g, path[p-1]), i) {
+				return nil, fmt.Errorf("%w: arrow %d jumps to cell %d", ErrInvalidRules, n+1, i)
+			}
+			a.arrowOf[i] = append(a.arrowOf[i], n)
+		}
+	}
+	return a, nil
+}
+
+// Arrows returns the arrows. The slice is shared and must not be
+// modified.
+func (a *Arrows) Arrows() []Arrow {
+	return a.arrows
+}
+
+// Regions returns none; digits may repeat along a shaft that crosses
+// boxes.
+func (a *Arrows) Regions(g Geometry) []Region {
+	return nil
+}
+
+// Allowed permits, in a circle, the sums the shaft can still reach and,
+// on a shaft, the digits that leave the rest of the shaft able to reach
+// the circle's digit, or some digit when the circle is empty.
+func (a *Arrows) Allowed(g Geometry, b Board, cell int) uint16 {
+	mask := g.FullMask()
+	for _, n := range a.arrowOf[cell] {
+		arrow := a.arrows[n]
+		sum, empty := 0, 0
+		for _, i := range arrow.Cells {
+			switch {
+			case i == cell:
+			case b[i] == 0:
+				empty++
+			default:
+				sum += int(b[i])
+			}
+		}
+		switch {
+		case cell == arrow.Circle:
+			mask &= digitRange(sum+empty, sum+empty*g.Size)
+		case b[arrow.Circle] == 0:
+			mask &= digitRange(1, g.Size-sum-empty)
+		default:
+			rest := int(b[arrow.Circle]) - sum
+			mask &= digitRange(rest-empty*g.Size, rest-empty)
+		}
+	}
+	return mask
+}
+
+// narrow keeps, on each arrow, the candidates that take part in some
+// way of filling the shaft that adds up to a digit the circle may hold.
+func (a *Arrows) narrow(g Geometry, b Board, cands []uint16) bool {
+	for _, arrow := range a.arrows {
+		masks, ok := arrowFits(g, b, cands, arrow)
+		if !ok {
+			return false
+		}
+		for p, i := range append([]int{arrow.Circle}, arrow.Cells...) {
+			if b[i] == 0 {
+				cands[i] &= masks[p]
+			}
+		}
+	}
+	return true
+}
+
+// arrowFits returns the digits the circle and then each shaft cell of an
+// arrow may hold: those that take part in some way of filling the shaft
+// from its cells' digits that adds up to a digit the circle may hold.
+// Filled cells start from their digit, empty ones from cands. It reports
+// false when a cell is left with none.
+func arrowFits(g Geometry, b Board, cands []uint16, arrow Arrow) ([]uint16, bool) {
+	path := append([]int{arrow.Circle}, arrow.Cells...)
+	masks := make([]uint16, len(path))
+	for p, i := range path {
+		masks[p] = cands[i]
+		if b[i] != 0 {
+			masks[p] = 1 << uint(b[i]-1)
+		}
+	}
+	// Sum sets have bit s set for each total s up to Size: before[k] for
+	// the shaft's first k cells, after[k] for its cells from k on.
+	n := len(arrow.Cells)
+	before, after := make([]uint32, n+1), make([]uint32, n+1)
+	before[0], after[n] = 1, 1
+	for k := 0; k < n; k++ {
+		before[k+1] = addSums(before[k], uint32(masks[k+1])<<1, g.Size)
+	}
+	for k := n - 1; k >= 0; k-- {
+		after[k] = addSums(after[k+1], uint32(masks[k+1])<<1, g.Size)
+	}
+	circle := uint32(masks[0]) << 1
+	masks[0] &= uint16(before[n] >> 1)
+	for k := 0; k < n; k++ {
+		others := addSums(before[k], after[k+1], g.Size)
+		var fit uint16
+		for _, d := range maskToValues(masks[k+1], g.Size) {
+			if others&(circle>>uint(d)) != 0 {
+				fit |= 1 << uint(d-1)
+			}
+		}
+		masks[k+1] = fit
+	}
+	for _, mask := range masks {
+		if mask == 0 {
+			return nil, false
+		}
+	}
+	return masks, true
+}
+
+// addSums returns the totals up to size of one total from a and one from
+// b, as sum sets.
+func addSums(a, b uint32, size int) uint32 {
+	var sums uint32
+	for s := 0; s <= size; s++ {
+		if a&(1<<uint(s)) != 0 {
+			sums |= b << uint(s)
+		}
+	}
+	return sums & (1<<uint(size+1) - 1)
+}
+
+// findArrowSum removes candidates that cannot balance an arrow: no way
+// of filling the shaft through them adds up to a digit the circle may
+// hold.
+func findArrowSum(gr *Grid) (Step, bool) {
+	a := gr.lay.arrowsOf()
+	if a == nil {
+		return Step{}, false
+	}
+	for _, arrow := range a.arrows {
+		masks, ok := arrowFits(gr.lay.geo, gr.values, gr.cands, arrow)
+		if !ok {
+			continue
+		}
+		path := append([]int{arrow.Circle}, arrow.Cells...)
+		var elims []Candidate
+		for p, i := range path {
+			if gr.values[i] != 0 {
+				continue
+			}
+			for _, d := range maskToValues(gr.cands[i]&^masks[p], gr.lay.geo.Size) {
+				elims = append(elims, Candidate{Cell: i, Digit: uint8(d)})
+			}
+		}
+		if len(elims) == 0 {
+			continue
+		}
+		return Step{Technique: ArrowSum, Cells: path, Eliminations: elims}, true
+	}
+	return Step{}, false
+}
+
+// arrowsOf returns the layout's arrow rule, if it has one.
+func (l *layout) arrowsOf() *Arrows {
+	for _, c := range l.constraints {
+		if a, ok := c.(*Arrows); ok {
+			return a
+		}
+	}
+	return nil
+}
+
+// arrowVariant is Arrow Sudoku: the classic rules plus arrows read off
+// the solution.
+type arrowVariant struct{}
+
+// ArrowVariant generates Arrow Sudoku puzzles.
+var ArrowVariant = registerVariant(arrowVariant{})
+
+// arrowMaxSize is the largest board Arrow puzzles are played on; on
+// bigger ones the search proving a puzzle unique takes too long.
+const arrowMaxSize = 10
+
+// arrowTries is how many circles per cell randomArrows tries.
+const arrowTries = 4
+
+// arrowClueShare is the share of a classic puzzle's givens an Arrow
+// puzzle keeps, per difficulty; the arrows carry the rest.
+var arrowClueShare = []float64{0.7, 0.6, 0.5, 0.45, 0.4, 0.4}
+
+// Name returns "arrow".
+func (arrowVariant) Name() string {
+	return "arrow"
+}
+
+// Base returns the standard rules on boards up to arrowMaxSize.
+func (arrowVariant) Base(g Geometry, r *rand.Rand) (*Rules, error) {
+	if g.Size > arrowMaxSize {
+		return nil, fmt.Errorf("%w: arrow is played on boards up to %dx%d, not %s", ErrInvalidGeometry, arrowMaxSize, arrowMaxSize, g)
+	}
+	return StandardRules(g)
+}
+
+// Decorate adds random arrows over the solution.
+func (arrowVariant) Decorate(base *Rules, solution Board, r *rand.Rand) (*Rules, error) {
+	g := base.Geometry()
+	a, err := NewArrows(g, randomArrows(r, g, solution))
+	if err != nil {
+		return nil, err
+	}
+	constraints := append(append([]Constraint(nil), base.Constraints()...), a)
+	return NewRules(g, constraints...)
+}
+
+// ClueTarget keeps a share of the classic target that shrinks with
+// difficulty.
+func (arrowVariant) ClueTarget(size int, d Difficulty) int {
+	if d < Easy || int(d) >= len(arrowClueShare) {
+		d = Hard
+	}
+	return int(float64(ClueTarget(size, d)) * arrowClueShare[d])
+}
+
+// randomArrows lays about one arrow per digit over the solution. Each
+// circle is a random free cell; its shaft wanders to random free
+// neighbours whose digits still fit under the circle's, and is kept when
+// it adds up to the circle exactly with at least two cells. Arrows never
+// share cells.
+func randomArrows(r *rand.Rand, g Geometry, solution Board) []Arrow {
+	used := make([]bool, g.Cells())
+	var arrows []Arrow
+	for n := 0; n < arrowTries*g.Cells() && len(arrows) < g.Size; n++ {
+		circle := r.Intn(g.Cells())
+		if used[circle] {
+			continue
+		}
+		used[circle] = true
+		var shaft []int
+		rest := int(solution[circle])
+		for last := circle; rest > 0; {
+			var options []int
+			for _, next := range neighbours(g, last) {
+				if !used[next] && int(solution[next]) <= rest {
+					options = append(options, next)
+				}
+			}
+			if len(options) == 0 {
+				break
+			}
+			last = options[r.Intn(len(options))]
+			used[last] = true
+			shaft = append(shaft, last)
+			rest -= int(solution[last])
+		}
+		if rest != 0 || len(shaft) < 2 {
+			used[circle] = false
+			for _, i := range shaft {
+				used[i] = false
+			}
+			continue
+		}
+		arrows = append(arrows, Arrow{Circle: circle, Cells: shaft})
+	}
+	return arrows
+}
@@ -0,0 +1,131 @@
+package sudoku
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// TestNewArrows checks which arrows NewArrows accepts.
+func TestNewArrows(t *testing.T) {
+	cases := []struct {
+		name   string
+		arrows []Arrow
+		ok     bool
+	}{
+		{"row", []Arrow{{Circle: 0, Cells: []int{1, 2}}}, true},
+		{"bend", []Arrow{{Circle: 0, Cells: []int{9, 10, 1}}}, true},
+		{"shared cell", []Arrow{{Circle: 0, Cells: []int{1}}, {Circle: 2, Cells: []int{1}}}, true},
+		{"no shaft", []Arrow{{Circle: 0}}, false},
+		{"too long", []Arrow{{Circle: 0, Cells: []int{1, 2, 3, 4, 5, 6, 7, 8, 17}}}, false},
+		{"apart from circle", []Arrow{{Circle: 0, Cells: []int{2, 3}}}, false},
+		{"back through circle", []Arrow{{Circle: 0, Cells: []int{1, 0}}}, false},
+		{"off board", []Arrow{{Circle: 80, Cells: []int{81}}}, false},
+	}
+	for _, c := range cases {
+		_, err := NewArrows(Geometry9, c.arrows)
+		if c.ok && err != nil || !c.ok && !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("%s: error %v", c.name, err)
+		}
+	}
+}
+
+// TestArrowAllowed checks the digits an arrow leaves its circle and shaft
+// for the digits filled along it.
+func TestArrowAllowed(t *testing.T) {
+	a, err := NewArrows(Geometry9, []Arrow{{Circle: 0, Cells: []int{1, 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		filled map[int]uint8
+		cell   int
+		want   string
+	}{
+		{"empty circle", nil, 0, "23456789"},
+		{"circle over a 3", map[int]uint8{1: 3}, 0, "456789"},
+		{"circle over 3 and 4", map[int]uint8{1: 3, 2: 4}, 0, "7"},
+		{"shaft", nil, 1, "12345678"},
+		{"shaft under a 5", map[int]uint8{0: 5}, 1, "1234"},
+		{"shaft under 5 by a 3", map[int]uint8{0: 5, 2: 3}, 1, "2"},
+		{"shaft under 3 by a 3", map[int]uint8{0: 3, 2: 3}, 1, ""},
+		{"off the arrow", nil, 40, "123456789"},
+	}
+	for _, c := range cases {
+		b := make(Board, Geometry9.Cells())
+		for i, v := range c.filled {
+			b[i] = v
+		}
+		if got, want := a.Allowed(Geometry9, b, c.cell), digitMask(c.want); got != want {
+			t.Errorf("%s: allowed %09b, want %09b", c.name, got, want)
+		}
+	}
+}
+
+// TestArrowFits checks the digits that take part in some shaft total the
+// circle can hold, circle first, and arrows left with none.
+func TestArrowFits(t *testing.T) {
+	arrow := Arrow{Circle: 0, Cells: []int{1, 2}}
+	cases := []struct {
+		name   string
+		filled map[int]uint8
+		cands  map[int]string
+		want   []string
+		ok     bool
+	}{
+		{"open", nil, nil, []string{"23456789", "12345678", "12345678"}, true},
+		{"circle of 3", nil, map[int]string{0: "3"}, []string{"3", "12", "12"}, true},
+		{"circle of 9 over a 4", nil, map[int]string{0: "9", 1: "4"}, []string{"9", "4", "5"}, true},
+		{"filled shaft", map[int]uint8{1: 3, 2: 4}, nil, []string{"7", "3", "4"}, true},
+		{"circle of 2 over a 2", nil, map[int]string{0: "2", 1: "2"}, nil, false},
+	}
+	for _, c := range cases {
+		b := make(Board, Geometry9.Cells())
+		for i, v := range c.filled {
+			b[i] = v
+		}
+		cands := make([]uint16, Geometry9.Cells())
+		for i := range cands {
+			cands[i] = Geometry9.FullMask()
+		}
+		for i, digits := range c.cands {
+			cands[i] = digitMask(digits)
+		}
+		masks, ok := arrowFits(Geometry9, b, cands, arrow)
+		if ok != c.ok {
+			t.Errorf("%s: arrowFits reported %v", c.name, ok)
+			continue
+		}
+		for p, digits := range c.want {
+			if masks[p] != digitMask(digits) {
+				t.Errorf("%s: cell %d fits %09b, want %s", c.name, p, masks[p], digits)
+			}
+		}
+	}
+}
+
+// TestArrowDecorate checks that generated arrows add up in the solution.
+func TestArrowDecorate(t *testing.T) {
+	solution := fixedPuzzle(t, Geometry9, Easy).Solution
+	r, err := ArrowVariant.Decorate(mustStandard(t, Geometry9), solution, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := r.lay.arrowsOf()
+	if a == nil || len(a.Arrows()) == 0 {
+		t.Fatal("no arrows")
+	}
+	for _, arrow := range a.Arrows() {
+		sum := 0
+		for _, i := range arrow.Cells {
+			sum += int(solution[i])
+		}
+		if len(arrow.Cells) < 2 || sum != int(solution[arrow.Circle]) {
+			t.Errorf("arrow %v adds up to %d, circle holds %d", arrow, sum, solution[arrow.Circle])
+		}
+	}
+	if err := r.Check(solution); err != nil {
+		t.Errorf("solution breaks the arrows: %v", err)
+	}
+}
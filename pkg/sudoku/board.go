@@ -0,0 +1,37 @@
+package sudoku
+
+// Board is a flat row-major grid; 0 marks an empty cell.
+type Board []uint8
+
+// Clone returns an independent copy of the board.
+func (b Board) Clone() Board {
+	dst := make(Board, len(b))
+	copy(dst, b)
+	return dst
+}
+
+// Filled returns the number of non-empty cells.
+func (b Board) Filled() int {
+	count := 0
+	for _, value := range b {
+		if value != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Complete reports whether every cell is filled.
+func (b Board) Complete() bool {
+	return b.Filled() == len(b)
+}
+
+// Check validates a board against the standard rules of a geometry:
+// length, digit range, and duplicate digits in a row, column, or box.
+func (g Geometry) Check(b Board) error {
+	r, err := StandardRules(g)
+	if err != nil {
+		return err
+	}
+	return r.Check(b)
+}
@@ -0,0 +1,228 @@
+package sudoku
+
+// maxChainLinks bounds chain length so searches stay interactive.
+const maxChainLinks = 14
+
+// chainKind restricts which links a chain search may use.
+type chainKind int
+
+const (
+	// chainX uses one digit: strong links are conjugate pairs in a unit.
+	chainX chainKind = iota
+	// chainXY uses bivalue cells: strong links stay inside a cell.
+	chainXY
+	// chainAIC mixes every strong and weak link.
+	chainAIC
+)
+
+// chainGraph holds candidate nodes and their links for a chain search.
+// Node ids are cell*size + digit-1.
+type chainGraph struct {
+	gr     *Grid
+	size   int
+	strong [][]int
+	weak   [][]int
+}
+
+// findXChain finds a single-digit alternating chain.
+func findXChain(gr *Grid) (Step, bool) {
+	return findChain(gr, chainX, XChain)
+}
+
+// findXYChain finds a chain of bivalue cells.
+func findXYChain(gr *Grid) (Step, bool) {
+	return findChain(gr, chainXY, XYChain)
+}
+
+// findAIC finds an alternating inference chain over any links.
+func findAIC(gr *Grid) (Step, bool) {
+	return findChain(gr, chainAIC, AlternatingChain)
+}
+
+// newChainGraph collects the links a chain kind is allowed to use.
+func newChainGraph(gr *Grid, kind chainKind) *chainGraph {
+	size := gr.lay.geo.Size
+	cg := &chainGraph{
+		gr:     gr,
+		size:   size,
+		strong: make([][]int, len(gr.cands)*size),
+		weak:   make([][]int, len(gr.cands)*size),
+	}
+	usable := func(cell int) bool {
+		return gr.cands[cell] != 0 && (kind != chainXY || bitCount(gr.cands[cell]) == 2)
+	}
+	for cell, mask := range gr.cands {
+		if !usable(cell) {
+			continue
+		}
+		for _, d := range maskToValues(mask, size) {
+			node := cell*size + d - 1
+			if kind != chainX {
+				for _, e := range maskToValues(mask, size) {
+					if e == d {
+						continue
+					}
+					other := cell*size + e - 1
+					if bitCount(mask) == 2 {
+						cg.strong[node] = append(cg.strong[node], other)
+					}
+					if kind == chainAIC {
+						cg.weak[node] = append(cg.weak[node], other)
+					}
+				}
+			}
+			for _, peer := range gr.lay.peers[cell] {
+				if usable(peer) && gr.cands[peer]&(1<<uint(d-1)) != 0 {
+					cg.weak[node] = append(cg.weak[node], peer*size+d-1)
+				}
+			}
+			if kind == chainXY {
+				continue
+			}
+			for _, u := range gr.lay.cellUnits[cell] {
+				cells := gr.digitCells(u, uint8(d))
+				if len(cells) != 2 {
+					continue
+				}
+				other := cells[0]
+				if other == cell {
+					other = cells[1]
+				}
+				if !containsInt(cg.strong[node], other*size+d-1) {
+					cg.strong[node] = append(cg.strong[node], other*size+d-1)
+				}
+			}
+		}
+	}
+	return cg
+}
+
+// candidate converts a node id back into a candidate.
+func (cg *chainGraph) candidate(node int) Candidate {
+	return Candidate{Cell: node / cg.size, Digit: uint8(node%cg.size + 1)}
+}
+
+// findChain runs a breadth-first search from every candidate for the
+// shortest alternating chain that starts and ends with a strong link and
+// proves an elimination. The chain shows that at least one of its end
+// candidates is true.
+func findChain(gr *Grid, kind chainKind, t Technique) (Step, bool) {
+	cg := newChainGraph(gr, kind)
+	nodes := len(cg.strong)
+	// States are node*2+parity; parity 1 means the node is true because
+	// it was reached over a strong link.
+	parent := make([]int, nodes*2)
+	depth := make([]int, nodes*2)
+	stamp := make([]int, nodes*2)
+	round := 0
+	for start := 0; start < nodes; start++ {
+		if len(cg.strong[start]) == 0 {
+			continue
+		}
+		round++
+		queue := []int{start * 2}
+		stamp[start*2] = round
+		parent[start*2] = -1
+		depth[start*2] = 0
+		for len(queue) > 0 {
+			state := queue[0]
+			queue = queue[1:]
+			node, parity := state/2, state%2
+			if parity == 1 && depth[state] >= 3 && node != start {
+				a, b := cg.candidate(start), cg.candidate(node)
+				if elims := chainEliminations(gr, a, b); len(elims) > 0 {
+					return Step{
+						Technique:    t,
+						Cells:        chainCells(cg, parent, state),
+						Digits:       chainDigits(cg, parent, state),
+						Chain:        chainLinks(cg, parent, state),
+						Eliminations: elims,
+					}, true
+				}
+			}
+			if depth[state] >= maxChainLinks {
+				continue
+			}
+			next := cg.strong[node]
+			if parity == 1 {
+				next = cg.weak[node]
+			}
+			for _, n := range next {
+				ns := n*2 + 1 - parity
+				if stamp[ns] == round {
+					continue
+				}
+				stamp[ns] = round
+				parent[ns] = state
+				depth[ns] = depth[state] + 1
+				queue = append(queue, ns)
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// chainEliminations lists what follows from "a or b is true".
+func chainEliminations(gr *Grid, a, b Candidate) []Candidate {
+	switch {
+	case a.Cell == b.Cell:
+		var elims []Candidate
+		keep := uint16(1)<<uint(a.Digit-1) | uint16(1)<<uint(b.Digit-1)
+		for _, d := range maskDigits(gr.cands[a.Cell]&^keep, gr.lay.geo.Size) {
+			elims = append(elims, Candidate{Cell: a.Cell, Digit: d})
+		}
+		return elims
+	case a.Digit == b.Digit:
+		return commonPeerElims(gr, a.Digit, a.Cell, b.Cell)
+	case gr.lay.sees(a.Cell, b.Cell):
+		var elims []Candidate
+		if gr.cands[a.Cell]&(1<<uint(b.Digit-1)) != 0 {
+			elims = append(elims, Candidate{Cell: a.Cell, Digit: b.Digit})
+		}
+		if gr.cands[b.Cell]&(1<<uint(a.Digit-1)) != 0 {
+			elims = append(elims, Candidate{Cell: b.Cell, Digit: a.Digit})
+		}
+		return elims
+	}
+	return nil
+}
+
+// chainLinks rebuilds the ordered links from the start to a state.
+func chainLinks(cg *chainGraph, parent []int, state int) []Link {
+	var links []Link
+	for parent[state] >= 0 {
+		prev := parent[state]
+		links = append(links, Link{
+			From:   cg.candidate(prev / 2),
+			To:     cg.candidate(state / 2),
+			Strong: state%2 == 1,
+		})
+		state = prev
+	}
+	for i, j := 0, len(links)-1; i < j; i, j = i+1, j-1 {
+		links[i], links[j] = links[j], links[i]
+	}
+	return links
+}
+
+// chainCells lists the distinct cells along a chain in order.
+func chainCells(cg *chainGraph, parent []int, state int) []int {
+	var cells []int
+	for _, link := range chainLinks(cg, parent, state) {
+		for _, c := range []int{link.From.Cell, link.To.Cell} {
+			if !containsInt(cells, c) {
+				cells = append(cells, c)
+			}
+		}
+	}
+	return cells
+}
+
+// chainDigits lists the distinct digits along a chain in ascending order.
+func chainDigits(cg *chainGraph, parent []int, state int) []uint8 {
+	mask := uint16(0)
+	for _, link := range chainLinks(cg, parent, state) {
+		mask |= 1<<uint(link.From.Digit-1) | 1<<uint(link.To.Digit-1)
+	}
+	return maskDigits(mask, cg.size)
+}
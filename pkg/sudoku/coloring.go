@@ -0,0 +1,85 @@
+package sudoku
+
+// findSimpleColoring colors each cluster of conjugate pairs for a digit
+// with two alternating colors; exactly one color is true. If two cells of
+// one color see each other, that color is false. Otherwise any cell that
+// sees both colors cannot hold the digit.
+func findSimpleColoring(gr *Grid) (Step, bool) {
+	size := gr.lay.geo.Size
+	for d := 1; d <= size; d++ {
+		digit := uint8(d)
+		mask := uint16(1) << uint(d-1)
+		partners := map[int][]int{}
+		for u := range gr.lay.units {
+			cells := gr.digitCells(u, digit)
+			if len(cells) != 2 {
+				continue
+			}
+			a, b := cells[0], cells[1]
+			if !containsInt(partners[a], b) {
+				partners[a] = append(partners[a], b)
+				partners[b] = append(partners[b], a)
+			}
+		}
+
+		color := map[int]int{}
+		for cell := range gr.cands {
+			if _, done := color[cell]; done || len(partners[cell]) == 0 {
+				continue
+			}
+			var links []Link
+			cluster := []int{cell}
+			color[cell] = 0
+			for k := 0; k < len(cluster); k++ {
+				c := cluster[k]
+				for _, p := range partners[c] {
+					if _, done := color[p]; done {
+						continue
+					}
+					color[p] = 1 - color[c]
+					cluster = append(cluster, p)
+					links = append(links, Link{
+						From:   Candidate{Cell: c, Digit: digit},
+						To:     Candidate{Cell: p, Digit: digit},
+						Strong: true,
+					})
+				}
+			}
+			if len(cluster) < 3 {
+				continue
+			}
+
+			step := Step{Technique: SimpleColoring, Cells: cluster, Digits: []uint8{digit}, Chain: links}
+			for _, a := range cluster {
+				for _, b := range cluster {
+					if a < b && color[a] == color[b] && gr.lay.sees(a, b) {
+						for _, c := range cluster {
+							if color[c] == color[a] {
+								step.Eliminations = append(step.Eliminations, Candidate{Cell: c, Digit: digit})
+							}
+						}
+						return step, true
+					}
+				}
+			}
+			for i, cands := range gr.cands {
+				if cands&mask == 0 || containsInt(cluster, i) {
+					continue
+				}
+				seen := [2]bool{}
+				for _, c := range cluster {
+					if gr.lay.sees(i, c) {
+						seen[color[c]] = true
+					}
+				}
+				if seen[0] && seen[1] {
+					step.Eliminations = append(step.Eliminations, Candidate{Cell: i, Digit: digit})
+				}
+			}
+			if len(step.Eliminations) > 0 {
+				return step, true
+			}
+		}
+	}
+	return Step{}, false
+}
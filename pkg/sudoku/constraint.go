@@ -0,0 +1,173 @@
+package sudoku
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Region is a group of cells whose digits must all differ. A region with
+// one cell per digit is a house: it holds every digit exactly once, and
+// the technique engine reasons about it like a row or box.
+type Region struct {
+	Unit  Unit
+	Cells []int
+}
+
+// Constraint is one rule of a puzzle. Regions lists the cells the rule
+// keeps distinct; Allowed narrows a cell's digits further for rules that
+// are not about distinct digits, such as sums or orderings.
+//
+// Allowed must be exact once every other cell of the rule is filled: it
+// then permits precisely the digits that satisfy the rule. With cells
+// still empty it may permit more, but never less.
+type Constraint interface {
+	// Regions returns the rule's regions on a geometry.
+	Regions(g Geometry) []Region
+	// Allowed returns the digits the rule permits in a cell given the
+	// other filled cells of b; the cell's own value is ignored.
+	Allowed(g Geometry, b Board, cell int) uint16
+}
+
+// narrower is implemented by constraints that can shrink the candidates
+// of several empty cells at once, beyond what Allowed sees one cell at a
+// time. The search uses it to prune; it reports false when the rule can
+// no longer be met.
+type narrower interface {
+	narrow(g Geometry, b Board, cands []uint16) bool
+}
+
+// Houses is the constraint that every unit of one kind holds each digit
+// once.
+type Houses UnitKind
+
+// The classic rules.
+const (
+	Rows    = Houses(RowUnit)
+	Columns = Houses(ColumnUnit)
+	Boxes   = Houses(BoxUnit)
+)
+
+// Regions returns one house per row, column, or box.
+func (h Houses) Regions(g Geometry) []Region {
+	regions := make([]Region, g.Size)
+	for i := range regions {
+		u := Unit{Kind: UnitKind(h), Index: i}
+		regions[i] = Region{Unit: u, Cells: g.UnitCells(u)}
+	}
+	return regions
+}
+
+// Allowed permits every digit; houses only forbid repeats.
+func (h Houses) Allowed(g Geometry, b Board, cell int) uint16 {
+	return g.FullMask()
+}
+
+// RegionSet is a constraint made only of fixed regions, such as extra
+// diagonals or irregular boxes.
+type RegionSet []Region
+
+// Regions returns the set itself.
+func (rs RegionSet) Regions(g Geometry) []Region {
+	return rs
+}
+
+// Allowed permits every digit; regions only forbid repeats.
+func (rs RegionSet) Allowed(g Geometry, b Board, cell int) uint16 {
+	return g.FullMask()
+}
+
+// Rules is a geometry together with the constraints its solutions obey.
+// It caches the tables the solvers need, so build it once and share it; it
+// is safe for concurrent use.
+type Rules struct {
+	lay *layout
+}
+
+// NewRules checks a geometry and its constraints and builds their tables.
+// The classic rules are StandardConstraints.
+func NewRules(g Geometry, constraints ...Constraint) (*Rules, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	for _, c := range constraints {
+		for _, r := range c.Regions(g) {
+			if len(r.Cells) > g.Size {
+				return nil, fmt.Errorf("%w: %s has %d cells", ErrInvalidRules, r.Unit, len(r.Cells))
+			}
+			for _, i := range r.Cells {
+				if i < 0 || i >= g.Cells() {
+					return nil, fmt.Errorf("%w: %s has cell %d outside %s", ErrInvalidRules, r.Unit, i, g)
+				}
+			}
+		}
+	}
+	return &Rules{lay: buildLayout(g, constraints)}, nil
+}
+
+// StandardConstraints returns rows, columns, and boxes.
+func StandardConstraints() []Constraint {
+	return []Constraint{Rows, Columns, Boxes}
+}
+
+// standardRules caches the classic rules by geometry.
+var standardRules sync.Map
+
+// StandardRules returns the shared classic rules for a geometry.
+func StandardRules(g Geometry) (*Rules, error) {
+	if r, ok := standardRules.Load(g); ok {
+		return r.(*Rules), nil
+	}
+	r, err := NewRules(g, StandardConstraints()...)
+	if err != nil {
+		return nil, err
+	}
+	stored, _ := standardRules.LoadOrStore(g, r)
+	return stored.(*Rules), nil
+}
+
+// Geometry returns the rules' geometry.
+func (r *Rules) Geometry() Geometry {
+	return r.lay.geo
+}
+
+// Constraints returns the rules' constraints. The slice is shared and
+// must not be modified.
+func (r *Rules) Constraints() []Constraint {
+	return r.lay.constraints
+}
+
+// Regions returns every region of the rules, houses first. The slice is
+// shared and must not be modified.
+func (r *Rules) Regions() []Region {
+	return r.lay.regions
+}
+
+// Classic reports whether the rules are exactly rows, columns, and boxes.
+func (r *Rules) Classic() bool {
+	return r.lay.classic
+}
+
+// RegionsOnly reports whether every constraint is made of regions alone,
+// so placing a digit only affects the candidates of the cell's peers.
+func (r *Rules) RegionsOnly() bool {
+	return len(r.lay.pruners) == 0
+}
+
+// Check validates a board against the rules: length, digit range, and
+// every constraint.
+func (r *Rules) Check(b Board) error {
+	st, err := r.NewState(b)
+	if err != nil {
+		return err
+	}
+	if !st.HasConflicts() {
+		return nil
+	}
+	g := r.lay.geo
+	for i, value := range b {
+		if st.Conflict(i) {
+			return &CellError{Row: i / g.Size, Col: i % g.Size, Value: value, Reason: st.conflictReason(i)}
+		}
+	}
+	return nil
+}
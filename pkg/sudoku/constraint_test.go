@@ -0,0 +1,106 @@
+package sudoku
+
+import (
+	"errors"
+	"testing"
+)
+
+// evenCells is a test constraint that only allows even digits in its
+// cells and has no regions.
+type evenCells []int
+
+func (e evenCells) Regions(g Geometry) []Region {
+	return nil
+}
+
+func (e evenCells) Allowed(g Geometry, b Board, cell int) uint16 {
+	for _, i := range e {
+		if i == cell {
+			return g.FullMask() & 0b1010101010101010
+		}
+	}
+	return g.FullMask()
+}
+
+// TestNewRules checks which constraints NewRules accepts and what the
+// resulting rules report.
+func TestNewRules(t *testing.T) {
+	diagonal := Region{Unit: Unit{Kind: ExtraUnit, Name: "diagonal"}, Cells: []int{0, 5, 10, 15}}
+	cases := []struct {
+		name        string
+		g           Geometry
+		constraints []Constraint
+		err         error
+		classic     bool
+		regionsOnly bool
+		regions     int
+	}{
+		{"standard", Geometry4, StandardConstraints(), nil, true, true, 12},
+		{"extra region", Geometry4, append(StandardConstraints(), RegionSet{diagonal}), nil, false, true, 13},
+		{"pruner", Geometry4, append(StandardConstraints(), evenCells{0}), nil, false, false, 12},
+		{"long region", Geometry4, []Constraint{RegionSet{{Cells: []int{0, 1, 2, 3, 4}}}}, ErrInvalidRules, false, false, 0},
+		{"cell off board", Geometry4, []Constraint{RegionSet{{Cells: []int{0, 16}}}}, ErrInvalidRules, false, false, 0},
+		{"bad geometry", Geometry{Size: 6, BoxRows: 2, BoxCols: 2}, StandardConstraints(), ErrInvalidGeometry, false, false, 0},
+	}
+	for _, c := range cases {
+		r, err := NewRules(c.g, c.constraints...)
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Errorf("%s: error %v, want %v", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if r.Classic() != c.classic || r.RegionsOnly() != c.regionsOnly || len(r.Regions()) != c.regions {
+			t.Errorf("%s: classic %v, regions only %v, %d regions", c.name, r.Classic(), r.RegionsOnly(), len(r.Regions()))
+		}
+	}
+	a, _ := StandardRules(Geometry9)
+	b, _ := StandardRules(Geometry9)
+	if a != b {
+		t.Error("StandardRules built the 9x9 rules twice")
+	}
+}
+
+// TestConstraintAllowed checks that a constraint's Allowed narrows
+// candidates, the solution, and board checks.
+func TestConstraintAllowed(t *testing.T) {
+	r, err := NewRules(Geometry4, append(StandardConstraints(), evenCells{0, 5})...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := make(Board, Geometry4.Cells())
+	if got := r.CandidateMask(empty, 0, 0); got != 0b1010 {
+		t.Errorf("r1c1 candidates %04b, want 1010", got)
+	}
+	if got := r.CandidateMask(empty, 0, 1); got != 0b1111 {
+		t.Errorf("r1c2 candidates %04b, want 1111", got)
+	}
+
+	b := empty.Clone()
+	b[0] = 3
+	var cellErr *CellError
+	if err := r.Check(b); !errors.As(err, &cellErr) || cellErr.Row != 0 || cellErr.Col != 0 || cellErr.Reason != "breaks a constraint" {
+		t.Errorf("odd r1c1: error %v, want a broken constraint at r1c1", err)
+	}
+	b[0], b[1] = 2, 2
+	if err := r.Check(b); !errors.As(err, &cellErr) || cellErr.Reason != "duplicate digit" {
+		t.Errorf("repeated 2: error %v, want a duplicate digit", err)
+	}
+
+	// Relabelling digits maps solutions onto each other, so an even digit
+	// in r1c1 keeps half of the 288 4x4 grids, and the other even digit
+	// in r2c2, of the three its box leaves, a third of those.
+	standard, _ := StandardRules(Geometry4)
+	one, _ := NewRules(Geometry4, append(StandardConstraints(), evenCells{0})...)
+	for _, c := range []struct {
+		r    *Rules
+		want int
+	}{{standard, 288}, {one, 144}, {r, 48}} {
+		if n, err := c.r.CountSolutions(empty, 1000); err != nil || n != c.want {
+			t.Errorf("%d solutions (%v), want %d", n, err, c.want)
+		}
+	}
+}
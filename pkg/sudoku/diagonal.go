@@ -0,0 +1,24 @@
+package sudoku
+
+// Diagonals returns the two main diagonals of a geometry as houses, the
+// extra rule of X-Sudoku.
+func Diagonals(g Geometry) RegionSet {
+	main, anti := make([]int, g.Size), make([]int, g.Size)
+	for i := 0; i < g.Size; i++ {
+		main[i] = g.Index(i, i)
+		anti[i] = g.Index(i, g.Size-1-i)
+	}
+	return RegionSet{
+		{Unit: Unit{Kind: ExtraUnit, Index: 0, Name: "main diagonal"}, Cells: main},
+		{Unit: Unit{Kind: ExtraUnit, Index: 1, Name: "anti-diagonal"}, Cells: anti},
+	}
+}
+
+// XVariant generates X-Sudoku puzzles, where both main diagonals also
+// hold every digit once.
+var XVariant = registerVariant(&fixedVariant{
+	name: "x",
+	extra: func(g Geometry) []Constraint {
+		return []Constraint{Diagonals(g)}
+	},
+})
@@ -0,0 +1,63 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestDiagonals checks the cells of both diagonals.
+func TestDiagonals(t *testing.T) {
+	cases := []struct {
+		g          Geometry
+		main, anti []int
+	}{
+		{Geometry4, []int{0, 5, 10, 15}, []int{3, 6, 9, 12}},
+		{Geometry6, []int{0, 7, 14, 21, 28, 35}, []int{5, 10, 15, 20, 25, 30}},
+	}
+	for _, c := range cases {
+		d := Diagonals(c.g)
+		if len(d) != 2 || !slices.Equal(d[0].Cells, c.main) || !slices.Equal(d[1].Cells, c.anti) {
+			t.Errorf("%s: diagonals %v", c.g, d)
+		}
+	}
+}
+
+// TestXVariantRules checks that X-Sudoku rejects a digit repeated on a
+// diagonal only and that its puzzles keep the diagonals distinct.
+func TestXVariantRules(t *testing.T) {
+	r, err := XVariant.Base(Geometry9, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Classic() || !r.RegionsOnly() || len(r.Regions()) != 29 {
+		t.Errorf("X rules: classic %v, regions only %v, %d regions", r.Classic(), r.RegionsOnly(), len(r.Regions()))
+	}
+	b := make(Board, Geometry9.Cells())
+	b[Geometry9.Index(0, 0)] = 1
+	b[Geometry9.Index(8, 8)] = 1
+	if err := r.Check(b); !errors.Is(err, ErrInvalidBoard) {
+		t.Errorf("repeat on the main diagonal: error %v", err)
+	}
+	if err := mustStandard(t, Geometry9).Check(b); err != nil {
+		t.Errorf("classic rules reject the diagonal repeat: %v", err)
+	}
+
+	p, err := GenerateWith(context.Background(), Geometry6, Easy, Options{Seed: 1, Variant: XVariant})
+	if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+		t.Fatal(err)
+	}
+	for _, d := range Diagonals(Geometry6) {
+		seen := uint16(0)
+		for _, i := range d.Cells {
+			seen |= 1 << uint(p.Solution[i]-1)
+		}
+		if seen != Geometry6.FullMask() {
+			t.Errorf("%s repeats a digit in %v", d.Unit, p.Solution)
+		}
+	}
+	if got, err := p.Rules.Solve(p.Givens); err != nil || !slices.Equal(got, p.Solution) {
+		t.Errorf("solving the X puzzle: %v", err)
+	}
+}
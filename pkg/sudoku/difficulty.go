@@ -0,0 +1,84 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Difficulty is a puzzle difficulty bucket, defined by the hardest
+// technique a puzzle needs (see Technique.Difficulty):
+//
+//   - Easy: hidden and naked singles.
+//   - Medium: pointing candidates, box/line reduction, naked and hidden
+//     pairs and triples.
+//   - Hard: X-Wing, Swordfish, XY-, XYZ- and W-Wings.
+//   - Expert: unique rectangles, quads, Jellyfish, BUG+1, simple coloring.
+//   - Evil: X-chains, XY-chains, alternating inference chains.
+//   - Diabolical: beyond the technique engine; needs trial and error.
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+	Expert
+	Evil
+	Diabolical
+)
+
+// Difficulties lists every bucket from easiest to hardest.
+var Difficulties = []Difficulty{Easy, Medium, Hard, Expert, Evil, Diabolical}
+
+// String returns the display label for a difficulty.
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "Easy"
+	case Medium:
+		return "Medium"
+	case Hard:
+		return "Hard"
+	case Expert:
+		return "Expert"
+	case Evil:
+		return "Evil"
+	case Diabolical:
+		return "Diabolical"
+	default:
+		return fmt.Sprintf("Difficulty(%d)", int(d))
+	}
+}
+
+// ParseDifficulty converts a case-insensitive label into a difficulty.
+func ParseDifficulty(value string) (Difficulty, error) {
+	for _, d := range Difficulties {
+		if strings.EqualFold(value, d.String()) {
+			return d, nil
+		}
+	}
+	return Easy, fmt.Errorf("%w: %q", ErrUnknownDifficulty, value)
+}
+
+// clueTargets lists the givens the generator aims for per size, indexed
+// by difficulty.
+var clueTargets = map[int][]int{
+	4:  {10, 8, 6, 6, 6, 6},
+	6:  {20, 16, 12, 11, 10, 10},
+	8:  {28, 23, 18, 17, 16, 16},
+	9:  {36, 30, 24, 23, 22, 22},
+	10: {44, 37, 30, 28, 27, 27},
+	12: {62, 52, 42, 40, 38, 38},
+	16: {124, 116, 110, 106, 102, 98},
+}
+
+// ClueTarget returns the number of givens the generator aims for. Sizes
+// without their own targets scale the 9x9 targets to the board's area.
+func ClueTarget(size int, d Difficulty) int {
+	if d < Easy || int(d) >= len(Difficulties) {
+		d = Hard
+	}
+	if targets, ok := clueTargets[size]; ok {
+		return targets[d]
+	}
+	return clueTargets[9][d] * size * size / 81
+}
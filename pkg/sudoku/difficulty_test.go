@@ -0,0 +1,85 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestGenerateMatchesDifficulty checks that a match comes back without an
+// error and that Rate agrees with its label.
+func TestGenerateMatchesDifficulty(t *testing.T) {
+	cases := []struct {
+		g Geometry
+		d Difficulty
+	}{
+		{Geometry6, Easy},
+		{Geometry6, Medium},
+		{Geometry9, Easy},
+		{Geometry9, Medium},
+		{Geometry9, Hard},
+	}
+	for _, c := range cases {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		p, err := GenerateWith(ctx, c.g, c.d, Options{Seed: 1})
+		cancel()
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.g, c.d, err)
+		}
+		rating, err := Rate(c.g, p.Givens)
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.g, c.d, err)
+		}
+		if p.Difficulty != c.d || rating.Difficulty != c.d || p.ID.Difficulty != c.d {
+			t.Errorf("%s %s: labelled %s, ID %s, rated %s", c.g, c.d, p.Difficulty, p.ID.Difficulty, rating.Difficulty)
+		}
+	}
+}
+
+// TestGenerateWrongDifficulty checks that a search that cannot match
+// returns its closest puzzle with its actual difficulty, and that a
+// search cut short says so.
+func TestGenerateWrongDifficulty(t *testing.T) {
+	// 4x4 puzzles never need more than intersections.
+	p, err := GenerateWith(context.Background(), Geometry4, Evil, Options{Seed: 1})
+	if !errors.Is(err, ErrWrongDifficulty) {
+		t.Fatalf("4x4 Evil: error %v, want ErrWrongDifficulty", err)
+	}
+	if p.Givens == nil || p.Difficulty == Evil || p.ID.Difficulty != p.Difficulty {
+		t.Errorf("4x4 Evil: closest puzzle labelled %s with ID %s", p.Difficulty, p.ID)
+	}
+	if rating, err := Rate(Geometry4, p.Givens); err != nil || rating.Difficulty != p.Difficulty {
+		t.Errorf("4x4 Evil: closest labelled %s, rated %s (%v)", p.Difficulty, rating.Difficulty, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = GenerateWith(ctx, Geometry9, Hard, Options{Seed: 1})
+	if !errors.Is(err, ErrGenerationFailed) || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: error %v, want ErrGenerationFailed and context.Canceled", err)
+	}
+}
+
+// TestClueTarget checks the per-size targets and the scaled targets of
+// sizes without their own.
+func TestClueTarget(t *testing.T) {
+	cases := []struct {
+		size int
+		d    Difficulty
+		want int
+	}{
+		{9, Easy, 36},
+		{9, Hard, 24},
+		{16, Diabolical, 98},
+		{9, Difficulty(-1), 24},
+		{15, Easy, 100},
+		{15, Hard, 66},
+		{14, Medium, 72},
+	}
+	for _, c := range cases {
+		if got := ClueTarget(c.size, c.d); got != c.want {
+			t.Errorf("ClueTarget(%d, %s) = %d, want %d", c.size, c.d, got, c.want)
+		}
+	}
+}
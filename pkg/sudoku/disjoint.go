@@ -0,0 +1,27 @@
+package sudoku
+
+import "fmt"
+
+// DisjointGroups returns the extra houses of Disjoint Groups Sudoku: for
+// each position within a box, the cells at that position in every box.
+func DisjointGroups(g Geometry) RegionSet {
+	groups := make(RegionSet, g.Size)
+	for i := range groups {
+		groups[i].Unit = Unit{Kind: ExtraUnit, Index: i, Name: fmt.Sprintf("disjoint group %d", i+1)}
+	}
+	for i := 0; i < g.Cells(); i++ {
+		row, col := i/g.Size, i%g.Size
+		n := (row%g.BoxRows)*g.BoxCols + col%g.BoxCols
+		groups[n].Cells = append(groups[n].Cells, i)
+	}
+	return groups
+}
+
+// DisjointVariant generates Disjoint Groups puzzles, where cells at the
+// same position in different boxes also hold different digits.
+var DisjointVariant = registerVariant(&fixedVariant{
+	name: "disjoint",
+	extra: func(g Geometry) []Constraint {
+		return []Constraint{DisjointGroups(g)}
+	},
+})
@@ -0,0 +1,55 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestDisjointGroups checks that the groups split the board by position
+// within a box.
+func TestDisjointGroups(t *testing.T) {
+	cases := []struct {
+		g     Geometry
+		group int
+		cells []int
+	}{
+		{Geometry4, 0, []int{0, 2, 8, 10}},
+		{Geometry6, 5, []int{8, 11, 20, 23, 32, 35}},
+		{Geometry9, 4, []int{10, 13, 16, 37, 40, 43, 64, 67, 70}},
+	}
+	for _, c := range cases {
+		groups := DisjointGroups(c.g)
+		if len(groups) != c.g.Size || !slices.Equal(groups[c.group].Cells, c.cells) {
+			t.Errorf("%s: %d groups, group %d %v", c.g, len(groups), c.group+1, groups[c.group].Cells)
+		}
+		seen := make([]bool, c.g.Cells())
+		for _, group := range groups {
+			for _, i := range group.Cells {
+				if seen[i] {
+					t.Errorf("%s: cell %d is in two groups", c.g, i)
+				}
+				seen[i] = true
+			}
+		}
+	}
+}
+
+// TestDisjointVariant checks that Disjoint Groups puzzles keep every
+// group distinct.
+func TestDisjointVariant(t *testing.T) {
+	p, err := GenerateWith(context.Background(), Geometry6, Easy, Options{Seed: 1, Variant: DisjointVariant})
+	if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+		t.Fatal(err)
+	}
+	for _, group := range DisjointGroups(Geometry6) {
+		seen := uint16(0)
+		for _, i := range group.Cells {
+			seen |= 1 << uint(p.Solution[i]-1)
+		}
+		if seen != Geometry6.FullMask() {
+			t.Errorf("%s repeats a digit in %v", group.Unit, p.Solution)
+		}
+	}
+}
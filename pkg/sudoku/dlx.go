@@ -0,0 +1,220 @@
+package sudoku
+
+// dlx is a Dancing Links exact-cover matrix for counting solutions.
+// Columns are "cell is filled" plus "house holds digit" for every house;
+// each candidate (cell, digit) is a row covering one cell column and one
+// column per house containing the cell. Only layouts whose regions are
+// all houses, with no other constraints, are an exact cover. Node 0 is the root header and
+// nodes 1..cols are column headers.
+type dlx struct {
+	left, right, up, down []int
+	col                   []int
+	rowID                 []int
+	size                  []int
+	digits                int
+	chosen                []int
+	first                 Board
+	base                  Board
+}
+
+// dlxTemplate is an unfilled matrix plus the first node of every row.
+type dlxTemplate struct {
+	matrix   *dlx
+	rowStart []int
+}
+
+// newDLX copies the cached exact-cover matrix for a layout and applies
+// givens. It returns false when the givens already conflict.
+func newDLX(l *layout, b Board) (*dlx, bool) {
+	t := templateFor(l)
+	m := t.matrix.clone()
+	n := l.geo.Size
+	for cell, value := range b {
+		if value == 0 {
+			continue
+		}
+		if !m.selectRow(t.rowStart[cell*n+int(value)-1]) {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+// templateFor returns the layout's template, building it on first use.
+func templateFor(l *layout) *dlxTemplate {
+	l.dlxOnce.Do(func() { l.dlx = buildTemplate(l) })
+	return l.dlx
+}
+
+// buildTemplate builds the empty exact-cover matrix for a layout.
+func buildTemplate(l *layout) *dlxTemplate {
+	n := l.geo.Size
+	cells := l.geo.Cells()
+	cols := cells + len(l.units)*n
+	rows := cells * n
+	nodes := 1 + cols + rows*(1+3)
+	m := &dlx{
+		left:   make([]int, cols+1, nodes),
+		right:  make([]int, cols+1, nodes),
+		up:     make([]int, cols+1, nodes),
+		down:   make([]int, cols+1, nodes),
+		col:    make([]int, cols+1, nodes),
+		rowID:  make([]int, cols+1, nodes),
+		size:   make([]int, cols+1),
+		digits: n,
+	}
+	for c := 0; c <= cols; c++ {
+		m.left[c] = c - 1
+		m.right[c] = c + 1
+		m.up[c] = c
+		m.down[c] = c
+		m.col[c] = c
+		m.rowID[c] = -1
+	}
+	m.left[0] = cols
+	m.right[cols] = 0
+
+	rowStart := make([]int, rows)
+	for cell := 0; cell < cells; cell++ {
+		for d := 0; d < n; d++ {
+			row := cell*n + d
+			columns := []int{1 + cell}
+			for _, u := range l.cellUnits[cell] {
+				columns = append(columns, 1+cells+u*n+d)
+			}
+			rowStart[row] = m.addRow(row, columns)
+		}
+	}
+	return &dlxTemplate{matrix: m, rowStart: rowStart}
+}
+
+// clone copies the link arrays so the copy can be covered independently.
+// Column and row ids never change and are shared.
+func (m *dlx) clone() *dlx {
+	return &dlx{
+		left:   append([]int(nil), m.left...),
+		right:  append([]int(nil), m.right...),
+		up:     append([]int(nil), m.up...),
+		down:   append([]int(nil), m.down...),
+		col:    m.col,
+		rowID:  m.rowID,
+		size:   append([]int(nil), m.size...),
+		digits: m.digits,
+	}
+}
+
+// addRow appends a circular row of nodes and returns its first node.
+func (m *dlx) addRow(row int, columns []int) int {
+	first := len(m.col)
+	for k, c := range columns {
+		node := len(m.col)
+		m.col = append(m.col, c)
+		m.rowID = append(m.rowID, row)
+		m.up = append(m.up, m.up[c])
+		m.down = append(m.down, c)
+		m.down[m.up[c]] = node
+		m.up[c] = node
+		m.size[c]++
+		if k == 0 {
+			m.left = append(m.left, node)
+			m.right = append(m.right, node)
+			continue
+		}
+		m.left = append(m.left, node-1)
+		m.right = append(m.right, first)
+		m.right[node-1] = node
+		m.left[first] = node
+	}
+	return first
+}
+
+// active reports whether a column header is still linked in.
+func (m *dlx) active(c int) bool {
+	return m.right[m.left[c]] == c
+}
+
+// selectRow covers every column of a row, fixing that candidate. It
+// returns false when an earlier selection already covers one of them.
+func (m *dlx) selectRow(node int) bool {
+	if !m.active(m.col[node]) {
+		return false
+	}
+	for j := m.right[node]; j != node; j = m.right[j] {
+		if !m.active(m.col[j]) {
+			return false
+		}
+	}
+	m.cover(m.col[node])
+	for j := m.right[node]; j != node; j = m.right[j] {
+		m.cover(m.col[j])
+	}
+	return true
+}
+
+// cover unlinks a column and every row that intersects it.
+func (m *dlx) cover(c int) {
+	m.right[m.left[c]] = m.right[c]
+	m.left[m.right[c]] = m.left[c]
+	for i := m.down[c]; i != c; i = m.down[i] {
+		for j := m.right[i]; j != i; j = m.right[j] {
+			m.down[m.up[j]] = m.down[j]
+			m.up[m.down[j]] = m.up[j]
+			m.size[m.col[j]]--
+		}
+	}
+}
+
+// uncover reverses cover.
+func (m *dlx) uncover(c int) {
+	for i := m.up[c]; i != c; i = m.up[i] {
+		for j := m.left[i]; j != i; j = m.left[j] {
+			m.size[m.col[j]]++
+			m.down[m.up[j]] = j
+			m.up[m.down[j]] = j
+		}
+	}
+	m.right[m.left[c]] = c
+	m.left[m.right[c]] = c
+}
+
+// count runs Algorithm X and returns the number of solutions up to
+// limit, counting nothing more once s stops. When base is set, the first
+// solution found is stored in first.
+func (m *dlx) count(s *stopper, limit int) int {
+	if s.stop() {
+		return 0
+	}
+	if m.right[0] == 0 {
+		if m.base != nil && m.first == nil {
+			m.first = m.base.Clone()
+			for _, row := range m.chosen {
+				m.first[row/m.digits] = uint8(row%m.digits + 1)
+			}
+		}
+		return 1
+	}
+	best := m.right[0]
+	for c := m.right[best]; c != 0; c = m.right[c] {
+		if m.size[c] < m.size[best] {
+			best = c
+		}
+	}
+	if m.size[best] == 0 {
+		return 0
+	}
+	total := 0
+	m.cover(best)
+	for r := m.down[best]; r != best && total < limit; r = m.down[r] {
+		for j := m.right[r]; j != r; j = m.right[j] {
+			m.cover(m.col[j])
+		}
+		m.chosen = append(m.chosen, m.rowID[r])
+		total += m.count(s, limit-total)
+		m.chosen = m.chosen[:len(m.chosen)-1]
+		for j := m.left[r]; j != r; j = m.left[j] {
+			m.uncover(m.col[j])
+		}
+	}
+	m.uncover(best)
+	return total
+}
@@ -0,0 +1,96 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fixedPuzzle returns the puzzle a fixed seed carves on a geometry. A
+// puzzle rated away from the difficulty still serves.
+func fixedPuzzle(tb testing.TB, g Geometry, d Difficulty) Puzzle {
+	tb.Helper()
+	p, err := GenerateWith(context.Background(), g, d, Options{Seed: 1})
+	if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+		tb.Fatalf("generate %s: %v", g, err)
+	}
+	return p
+}
+
+// unsolvable returns the givens plus a digit no peer rules out but the
+// solution does not hold, so that no solution is left.
+func unsolvable(tb testing.TB, p Puzzle) Board {
+	tb.Helper()
+	g := p.Geometry
+	for i, value := range p.Givens {
+		if value != 0 {
+			continue
+		}
+		mask := CandidateMask(g, p.Givens, i/g.Size, i%g.Size) &^ (1 << uint(p.Solution[i]-1))
+		if mask != 0 {
+			b := p.Givens.Clone()
+			b[i] = uint8(firstBit(mask))
+			return b
+		}
+	}
+	tb.Fatalf("%s: every empty cell is a naked single", g)
+	return nil
+}
+
+// TestCountersAgree checks that Dancing Links and backtracking count the
+// same solutions on unsolvable, unique and multi-solution grids.
+func TestCountersAgree(t *testing.T) {
+	for _, g := range Geometries {
+		p := fixedPuzzle(t, g, Easy)
+		firstRow := make(Board, g.Cells())
+		copy(firstRow, p.Solution[:g.Size])
+		cases := []struct {
+			name  string
+			board Board
+			want  int
+		}{
+			{"unsolvable", unsolvable(t, p), 0},
+			{"unique", p.Givens, 1},
+			{"multiple", firstRow, 2},
+		}
+		for _, c := range cases {
+			for _, limit := range []int{1, 2} {
+				want := min(c.want, limit)
+				for _, counter := range []Counter{BacktrackCounter, DLXCounter} {
+					got, err := CountSolutionsWith(counter, g, c.board, limit)
+					if err != nil {
+						t.Fatalf("%s %s %s limit %d: %v", g, c.name, counter, limit, err)
+					}
+					if got != want {
+						t.Errorf("%s %s %s limit %d: got %d solutions, want %d", g, c.name, counter, limit, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// benchmarkCounter counts the solutions of a fixed Hard puzzle on each
+// geometry with one backend.
+func benchmarkCounter(b *testing.B, c Counter) {
+	for _, g := range Geometries {
+		board := fixedPuzzle(b, g, Hard).Givens
+		b.Run(g.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := CountSolutionsWith(c, g, board, 2); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCountSolutionsBacktrack times the backtracking counter.
+func BenchmarkCountSolutionsBacktrack(b *testing.B) {
+	benchmarkCounter(b, BacktrackCounter)
+}
+
+// BenchmarkCountSolutionsDLX times the Dancing Links counter.
+func BenchmarkCountSolutionsDLX(b *testing.B) {
+	benchmarkCounter(b, DLXCounter)
+}
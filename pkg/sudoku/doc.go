@@ -0,0 +1,14 @@
+// Package sudoku is a reusable Sudoku engine: board geometries, solving,
+// solution counting, puzzle generation, and difficulty rating.
+//
+// Boards are flat row-major slices where 0 marks an empty cell and 1..Size
+// are placed digits. Candidate sets are bitmasks where bit n-1 stands for
+// digit n.
+//
+// The rules of a puzzle are a Geometry plus a list of Constraints, bundled
+// as Rules. Functions that take a Geometry use StandardRules; Rules
+// methods of the same name handle variants. Variants that read their
+// constraints off a solution, such as Killer cages, Jigsaw boxes,
+// thermometers, Kropki dots or arrows, implement Variant and are generated
+// through Options.Variant.
+package sudoku
@@ -0,0 +1,41 @@
+package sudoku
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by the engine. Use errors.Is to test for them.
+var (
+	ErrInvalidGeometry   = errors.New("sudoku: invalid geometry")
+	ErrInvalidBoard      = errors.New("sudoku: invalid board")
+	ErrInvalidRules      = errors.New("sudoku: invalid rules")
+	ErrNoSolution        = errors.New("sudoku: puzzle has no solution")
+	ErrMultipleSolutions = errors.New("sudoku: puzzle has multiple solutions")
+	ErrUnknownDifficulty = errors.New("sudoku: unknown difficulty")
+	ErrUnknownSymmetry   = errors.New("sudoku: unknown symmetry")
+	ErrUnknownVariant    = errors.New("sudoku: unknown variant")
+	ErrInvalidOptions    = errors.New("sudoku: invalid generation options")
+	ErrInvalidPuzzleID   = errors.New("sudoku: invalid puzzle id")
+	ErrGenerationFailed  = errors.New("sudoku: puzzle generation failed")
+	ErrWrongDifficulty   = errors.New("sudoku: no puzzle rated at the requested difficulty")
+)
+
+// CellError reports an invalid value at a specific cell.
+// It matches ErrInvalidBoard with errors.Is.
+type CellError struct {
+	Row    int
+	Col    int
+	Value  uint8
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *CellError) Error() string {
+	return fmt.Sprintf("sudoku: invalid board: r%dc%d value %d: %s", e.Row+1, e.Col+1, e.Value, e.Reason)
+}
+
+// Unwrap lets errors.Is match ErrInvalidBoard.
+func (e *CellError) Unwrap() error {
+	return ErrInvalidBoard
+}
@@ -0,0 +1,79 @@
+package sudoku
+
+// findFish finds n rows (or columns) whose candidates for a digit lie in
+// exactly n columns (or rows). The digit is then removed from the rest of
+// those cover lines. n=2 is an X-Wing, 3 a Swordfish, 4 a Jellyfish.
+// Rules without rows and columns have no fish.
+func findFish(gr *Grid, n int) (Step, bool) {
+	if gr.lay.unitOf(RowUnit, 0) < 0 || gr.lay.unitOf(ColumnUnit, 0) < 0 {
+		return Step{}, false
+	}
+	for d := 1; d <= gr.lay.geo.Size; d++ {
+		for _, kinds := range [][2]UnitKind{{RowUnit, ColumnUnit}, {ColumnUnit, RowUnit}} {
+			if step, ok := fishFor(gr, n, uint8(d), kinds[0], kinds[1]); ok {
+				return step, true
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// fishFor searches one digit and orientation for a fish of size n.
+func fishFor(gr *Grid, n int, digit uint8, baseKind, coverKind UnitKind) (Step, bool) {
+	t := [...]Technique{2: XWing, 3: Swordfish, 4: Jellyfish}[n]
+	size := gr.lay.geo.Size
+	var lines []int
+	var positions []uint16
+	for line := 0; line < size; line++ {
+		u := gr.lay.unitOf(baseKind, line)
+		pos := uint16(0)
+		for k, i := range gr.lay.unitCells[u] {
+			if gr.cands[i]&(1<<uint(digit-1)) != 0 {
+				pos |= 1 << uint(k)
+			}
+		}
+		if count := bitCount(pos); count >= 2 && count <= n {
+			lines = append(lines, line)
+			positions = append(positions, pos)
+		}
+	}
+
+	var found Step
+	ok := combinations(len(lines), n, func(pick []int) bool {
+		union := uint16(0)
+		var base []int
+		for _, p := range pick {
+			union |= positions[p]
+			base = append(base, lines[p])
+		}
+		if bitCount(union) != n {
+			return false
+		}
+		var cells []int
+		var baseUnits, coverUnits []Unit
+		for _, line := range base {
+			u := gr.lay.unitOf(baseKind, line)
+			baseUnits = append(baseUnits, gr.lay.units[u])
+			cells = append(cells, gr.digitCells(u, digit)...)
+		}
+		var elims []Candidate
+		for _, cover := range maskToValues(union, size) {
+			u := gr.lay.unitOf(coverKind, cover-1)
+			coverUnits = append(coverUnits, gr.lay.units[u])
+			elims = append(elims, eliminateOutside(gr, gr.lay.unitCells[u], cells, digit)...)
+		}
+		if len(elims) == 0 {
+			return false
+		}
+		found = Step{
+			Technique:    t,
+			Base:         baseUnits,
+			Cover:        coverUnits,
+			Cells:        cells,
+			Digits:       []uint8{digit},
+			Eliminations: elims,
+		}
+		return true
+	})
+	return found, ok
+}
@@ -0,0 +1,540 @@
+package sudoku
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// maxAttempts bounds how many carve attempts Generate makes per call when
+// the context has no deadline.
+const maxAttempts = 60
+
+// Puzzle is a generated puzzle with its unique solution. Rules are the
+// constraints it was made for; nil means the standard rules. ID
+// regenerates it with GenerateFromID and is zero for puzzles made under
+// other rules. Clues counts the givens and Minimal reports whether
+// removing any one of them would break uniqueness; it is left false when
+// generation ran out of time before that was checked.
+type Puzzle struct {
+	ID         PuzzleID
+	Geometry   Geometry
+	Rules      *Rules
+	Givens     Board
+	Solution   Board
+	Difficulty Difficulty
+	Score      float64
+	Symmetry   Symmetry
+	Clues      int
+	Minimal    bool
+}
+
+// Options tunes puzzle generation. The zero value carves without symmetry
+// towards the difficulty's ClueTarget.
+//
+// Minimal carves every removable clue instead of stopping at the target.
+// Without symmetry the result is always minimal; with symmetry, whole
+// orbits are removed, so single clues may still be removable and
+// Puzzle.Minimal says which.
+//
+// Clues, when positive, asks for exactly that many givens. Carves that end
+// elsewhere are discarded and generation restarts with a new grid until
+// one lands on the count, so ctx should carry a deadline. Combined with
+// Minimal, the exact-count puzzle must also be minimal.
+//
+// Seed drives the search; zero picks a random seed.
+//
+// Rules, when set, carves for those constraints instead of the standard
+// rules of the geometry; its geometry must match.
+//
+// Variant, when set, makes a puzzle of that family: each attempt gets its
+// own rules, such as cages read off its solution, and the puzzle carries
+// them in Rules. It cannot be combined with Rules.
+//
+// Workers, when positive, caps how many attempts run at once below
+// GOMAXPROCS, leaving the other cores free.
+type Options struct {
+	Symmetry Symmetry
+	Minimal  bool
+	Clues    int
+	Seed     int64
+	Rules    *Rules
+	Variant  Variant
+	Workers  int
+}
+
+// validate rejects clue counts the geometry cannot hold, negative worker
+// counts and rules for another geometry.
+func (o Options) validate(g Geometry) error {
+	if o.Clues < 0 || o.Clues > g.Cells() {
+		return fmt.Errorf("%w: %d clues for %s", ErrInvalidOptions, o.Clues, g)
+	}
+	if o.Workers < 0 {
+		return fmt.Errorf("%w: %d workers", ErrInvalidOptions, o.Workers)
+	}
+	if o.Rules != nil && o.Rules.Geometry() != g {
+		return fmt.Errorf("%w: rules for %s, not %s", ErrInvalidOptions, o.Rules.Geometry(), g)
+	}
+	if o.Rules != nil && o.Variant != nil {
+		return fmt.Errorf("%w: both rules and a variant", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// rules returns the rules to carve for.
+func (o Options) rules(g Geometry) (*Rules, error) {
+	if o.Rules != nil {
+		return o.Rules, nil
+	}
+	return StandardRules(g)
+}
+
+// variantName returns the name of the variant, or "" without one.
+func (o Options) variantName() string {
+	if o.Variant == nil {
+		return ""
+	}
+	return o.Variant.Name()
+}
+
+// attemptRules fills a solution and returns the rules to carve it under:
+// the options' rules, or the variant's base rules decorated for the
+// solution. It reports false if no solution was found.
+func (o Options) attemptRules(ctx context.Context, g Geometry, r *rand.Rand) (*Rules, Board, bool) {
+	if o.Variant == nil {
+		rules, err := o.rules(g)
+		if err != nil {
+			return nil, nil, false
+		}
+		solution, ok := rules.solution(ctx, r)
+		return rules, solution, ok
+	}
+	base, err := o.Variant.Base(g, r)
+	if err != nil {
+		return nil, nil, false
+	}
+	solution, ok := base.solution(ctx, r)
+	if !ok {
+		return nil, nil, false
+	}
+	rules, err := o.Variant.Decorate(base, solution, r)
+	if err != nil {
+		return nil, nil, false
+	}
+	return rules, solution, true
+}
+
+// targetClues returns how many givens the n-th carve attempt aims for.
+// Without an explicit count, the ClueTarget drops by one clue every
+// maxAttempts attempts so a long search reaches sparser, harder puzzles.
+func (o Options) targetClues(g Geometry, d Difficulty, n int64) int {
+	switch {
+	case o.Clues > 0:
+		return o.Clues
+	case o.Minimal:
+		return 0
+	case o.Variant != nil:
+		return max(o.Variant.ClueTarget(g.Size, d)-int(n/maxAttempts), 0)
+	default:
+		return max(ClueTarget(g.Size, d)-int(n/maxAttempts), 0)
+	}
+}
+
+// attemptLimit bounds carve attempts. With a deadline, or an exact clue
+// count, generation keeps restarting until ctx is done.
+func (o Options) attemptLimit(ctx context.Context) int64 {
+	if _, ok := ctx.Deadline(); ok || o.Clues > 0 {
+		return math.MaxInt64
+	}
+	return maxAttempts
+}
+
+// attempt is one worker's carved and rated puzzle; n is its index in the
+// search.
+type attempt struct {
+	n      int64
+	puzzle Puzzle
+	ok     bool
+}
+
+// Generate produces a uniquely solvable puzzle for a geometry rated at the
+// requested difficulty. After maxAttempts carves without a match it returns
+// the closest puzzle, labelled with its actual difficulty, and an error
+// wrapping ErrWrongDifficulty.
+func Generate(g Geometry, d Difficulty) (Puzzle, error) {
+	return GenerateContext(context.Background(), g, d)
+}
+
+// GenerateContext is Generate spread across GOMAXPROCS workers. If ctx has
+// a deadline, attempts continue until it passes instead of stopping at
+// maxAttempts. When ctx is done first, it returns an error wrapping
+// ErrGenerationFailed and ctx.Err(), together with the unique puzzle whose
+// rating came closest so far. That best-effort puzzle carries its actual
+// difficulty and has nil Givens if no attempt finished.
+func GenerateContext(ctx context.Context, g Geometry, d Difficulty) (Puzzle, error) {
+	return GenerateWith(ctx, g, d, Options{})
+}
+
+// GenerateWith is GenerateContext with generation options. It uses a
+// shared Generator without a library. Attempt n
+// draws from a seed derived from Options.Seed and n, and the lowest
+// matching attempt wins, so a fixed seed gives the same puzzle however the
+// workers are scheduled, as long as the search ends the same way: ctx
+// cutting it short, or maxAttempts running out without a deadline, can
+// change the outcome.
+func GenerateWith(ctx context.Context, g Geometry, d Difficulty, opts Options) (Puzzle, error) {
+	return defaultGenerator.carve(ctx, g, d, opts)
+}
+
+// carve runs the parallel carve search behind GenerateWith, drawing the
+// run seed from gen when opts has none.
+func (gen *Generator) carve(ctx context.Context, g Geometry, d Difficulty, opts Options) (Puzzle, error) {
+	if err := g.Validate(); err != nil {
+		return Puzzle{}, err
+	}
+	if err := opts.validate(g); err != nil {
+		return Puzzle{}, err
+	}
+	if opts.Seed == 0 {
+		opts.Seed = gen.seed()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := opts.attemptLimit(ctx)
+	workers := runtime.GOMAXPROCS(0)
+	if opts.Workers > 0 && opts.Workers < workers {
+		workers = opts.Workers
+	}
+	if workers > maxAttempts {
+		workers = maxAttempts
+	}
+	var started atomic.Int64
+	var stopAt atomic.Int64
+	stopAt.Store(math.MaxInt64)
+	results := make(chan attempt)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := started.Add(1) - 1; n < limit && n < stopAt.Load(); n = started.Add(1) - 1 {
+				id := PuzzleID{
+					Size:       g.Size,
+					Difficulty: d,
+					Version:    GeneratorVersion,
+					Variant:    opts.variantName(),
+					Symmetry:   opts.Symmetry,
+					Clues:      opts.targetClues(g, d, n),
+					Seed:       attemptSeed(opts.Seed, n),
+				}
+				p, ok := generateAttempt(ctx, g, id, opts)
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case results <- attempt{n: n, puzzle: p, ok: ok}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var best, match Puzzle
+	bestN, matchN := int64(math.MaxInt64), int64(math.MaxInt64)
+	for {
+		select {
+		case res := <-results:
+			if !res.ok {
+				continue
+			}
+			if res.puzzle.Difficulty == d {
+				if res.n < matchN {
+					match, matchN = res.puzzle, res.n
+					stopAt.Store(matchN)
+				}
+				continue
+			}
+			if best.Givens == nil || closer(res.puzzle.Difficulty, best.Difficulty, d) ||
+				(res.puzzle.Difficulty == best.Difficulty && res.n < bestN) {
+				best, bestN = res.puzzle, res.n
+			}
+		case <-done:
+			if match.Givens != nil {
+				return finishPuzzle(ctx, match), nil
+			}
+			if ctx.Err() != nil {
+				return generationTimeout(ctx, best)
+			}
+			if best.Givens == nil {
+				return best, ErrGenerationFailed
+			}
+			return finishPuzzle(ctx, best), fmt.Errorf("%w: wanted %s, closest was %s", ErrWrongDifficulty, d, best.Difficulty)
+		case <-ctx.Done():
+			if match.Givens != nil {
+				return finishPuzzle(ctx, match), nil
+			}
+			return generationTimeout(ctx, best)
+		}
+	}
+}
+
+// generationTimeout reports a search cut short by ctx with its best puzzle.
+func generationTimeout(ctx context.Context, best Puzzle) (Puzzle, error) {
+	if best.Givens != nil {
+		best = finishPuzzle(ctx, best)
+	}
+	return best, fmt.Errorf("%w: %w", ErrGenerationFailed, ctx.Err())
+}
+
+// closer reports whether a is nearer to want than b.
+func closer(a, b, want Difficulty) bool {
+	return abs(int(a)-int(want)) < abs(int(b)-int(want))
+}
+
+// abs returns the absolute value of an int.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// finishPuzzle fills in the clue count and minimality of a result.
+// Proving minimality under sums can take seconds, so a done ctx leaves
+// Minimal false rather than overrunning the deadline.
+func finishPuzzle(ctx context.Context, p Puzzle) Puzzle {
+	p.Clues = p.Givens.Filled()
+	if r, err := p.rules(); err == nil {
+		p.Minimal = isMinimal(ctx, p.Givens, r.lay)
+	}
+	return p
+}
+
+// rules returns the puzzle's rules, standard when unset.
+func (p Puzzle) rules() (*Rules, error) {
+	if p.Rules != nil {
+		return p.Rules, nil
+	}
+	return StandardRules(p.Geometry)
+}
+
+// generateAttempt carves one puzzle for the options' rules or variant from
+// the seed, symmetry, and clue target in id and rates it; the returned
+// puzzle's ID carries its actual difficulty, or is zero under custom
+// rules. It reports false when the carve was interrupted, did not end with
+// a unique solution, or missed an exact clue count the options ask for.
+func generateAttempt(ctx context.Context, g Geometry, id PuzzleID, opts Options) (Puzzle, bool) {
+	r := rand.New(rand.NewSource(id.Seed))
+	rules, solution, ok := opts.attemptRules(ctx, g, r)
+	if !ok {
+		return Puzzle{}, false
+	}
+	l := rules.lay
+	givens := carvePuzzle(ctx, r, solution, l, id.Clues, id.Symmetry)
+	if ctx.Err() != nil || !unique(ctx, givens, l) {
+		return Puzzle{}, false
+	}
+	if opts.Clues > 0 && givens.Filled() != opts.Clues {
+		return Puzzle{}, false
+	}
+	if opts.Clues > 0 && opts.Minimal && !isMinimal(ctx, givens, l) {
+		return Puzzle{}, false
+	}
+	gr, err := rules.NewGrid(givens)
+	if err != nil {
+		return Puzzle{}, false
+	}
+	rating := rateGrid(gr, solution)
+	id.Difficulty = rating.Difficulty
+	if !rules.Classic() && opts.Variant == nil {
+		id = PuzzleID{}
+	}
+	if opts.Variant == nil {
+		rules = opts.Rules
+	}
+	return Puzzle{
+		ID:         id,
+		Geometry:   g,
+		Rules:      rules,
+		Givens:     givens,
+		Solution:   solution,
+		Difficulty: rating.Difficulty,
+		Score:      rating.Score,
+		Symmetry:   id.Symmetry,
+	}, true
+}
+
+// carvePuzzle removes values from a solved grid while keeping uniqueness.
+// Cells are removed an orbit of sym at a time, so an orbit that would
+// overshoot the target is kept. It stops early, leaving extra clues, when
+// ctx is done; a uniqueness check cut short keeps its orbit.
+func carvePuzzle(ctx context.Context, r *rand.Rand, solution Board, l *layout, targetClues int, sym Symmetry) Board {
+	givens := solution.Clone()
+	if targetClues < 0 {
+		targetClues = 0
+	}
+	if targetClues > len(givens) {
+		targetClues = len(givens)
+	}
+	removeCount := len(givens) - targetClues
+	removed := 0
+	orbits := sym.Orbits(l.geo)
+	for _, o := range r.Perm(len(orbits)) {
+		orbit := orbits[o]
+		if removed >= removeCount || ctx.Err() != nil {
+			break
+		}
+		if removed+len(orbit) > removeCount {
+			continue
+		}
+		for _, i := range orbit {
+			givens[i] = 0
+		}
+		if !unique(ctx, givens, l) {
+			for _, i := range orbit {
+				givens[i] = solution[i]
+			}
+			continue
+		}
+		removed += len(orbit)
+	}
+	return givens
+}
+
+// proofTechniques are the cheap steps unique tries before searching.
+var proofTechniques = []Technique{HiddenSingle, NakedSingle, ThermoOrder, KropkiDot, ArrowSum, CageCombination, PointingCandidates, InnieOutie, BoxLineReduction}
+
+// unique reports whether a board has exactly one solution. Searching is
+// slow under sums and other pruners, so there it first tries to finish
+// the board with a few sound techniques: a board they fill has only that
+// solution. It reports false when ctx is done before the count is.
+func unique(ctx context.Context, b Board, l *layout) bool {
+	if len(l.pruners) > 0 && solvesSimply(b, l) {
+		return true
+	}
+	return countSolutionsContext(ctx, b, l, 2) == 1 && ctx.Err() == nil
+}
+
+// solvesSimply reports whether proofTechniques alone fill a board.
+func solvesSimply(b Board, l *layout) bool {
+	gr, err := (&Rules{lay: l}).NewGrid(b)
+	if err != nil {
+		return false
+	}
+	for !gr.Solved() {
+		if gr.Broken() {
+			return false
+		}
+		progressed := false
+		for _, t := range proofTechniques {
+			if step, ok := finders[t](gr); ok {
+				gr.Apply(step)
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateSolution builds a full valid grid by permuting a base pattern.
+func GenerateSolution(g Geometry) Board {
+	return defaultGenerator.Solution(g)
+}
+
+// generateSolution builds a full grid from a specific random source.
+func generateSolution(r *rand.Rand, g Geometry) Board {
+	size := g.Size
+	base := make(Board, size*size)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			value := (row*g.BoxCols + row/g.BoxRows + col) % size
+			base[g.Index(row, col)] = uint8(value + 1)
+		}
+	}
+
+	digitPerm := r.Perm(size)
+	for i := range base {
+		base[i] = uint8(digitPerm[base[i]-1] + 1)
+	}
+
+	rowOrder := shuffledBandIndices(r, size, g.BoxRows)
+	colOrder := shuffledBandIndices(r, size, g.BoxCols)
+	grid := make(Board, size*size)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			grid[g.Index(row, col)] = base[g.Index(rowOrder[row], colOrder[col])]
+		}
+	}
+	return grid
+}
+
+// solution builds a random full grid that obeys the rules. The classic
+// rules permute a base pattern; others fill the grid by randomized
+// backtracking, which reports false if ctx ends first or no grid exists.
+func (rules *Rules) solution(ctx context.Context, r *rand.Rand) (Board, bool) {
+	if rules.Classic() {
+		return generateSolution(r, rules.lay.geo), true
+	}
+	st := newState(rules.lay, make(Board, rules.lay.geo.Cells()))
+	if !fillRandom(ctx, r, st) {
+		return nil, false
+	}
+	return st.Values(), true
+}
+
+// fillRandom completes a state by backtracking, branching on the empty
+// cell with the fewest candidates and trying its digits in random order.
+func fillRandom(ctx context.Context, r *rand.Rand, st *State) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	cell, count := -1, st.lay.geo.Size+1
+	for i, value := range st.values {
+		if value != 0 {
+			continue
+		}
+		if n := bitCount(st.Candidates(i)); n < count {
+			cell, count = i, n
+		}
+	}
+	if cell == -1 {
+		return true
+	}
+	values := maskToValues(st.Candidates(cell), st.lay.geo.Size)
+	for _, k := range r.Perm(len(values)) {
+		st.Set(cell, uint8(values[k]))
+		if fillRandom(ctx, r, st) {
+			return true
+		}
+	}
+	st.Set(cell, 0)
+	return false
+}
+
+// shuffledBandIndices randomizes rows/cols by bands to preserve validity.
+func shuffledBandIndices(r *rand.Rand, size, band int) []int {
+	bands := size / band
+	bandOrder := r.Perm(bands)
+	order := make([]int, 0, size)
+	for _, b := range bandOrder {
+		for _, i := range r.Perm(band) {
+			order = append(order, b*band+i)
+		}
+	}
+	return order
+}
@@ -0,0 +1,125 @@
+package sudoku
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Library is a source of ready-made puzzles that a Generator serves before
+// carving new ones. Implementations must be safe for concurrent use and
+// must not modify the returned puzzles.
+type Library interface {
+	Puzzles(g Geometry, d Difficulty) []Puzzle
+}
+
+// libraryKey indexes library puzzles by geometry and difficulty.
+type libraryKey struct {
+	geo Geometry
+	d   Difficulty
+}
+
+// MemoryLibrary is an in-memory Library. It is safe for concurrent use.
+type MemoryLibrary struct {
+	mu      sync.RWMutex
+	puzzles map[libraryKey][]Puzzle
+}
+
+// NewMemoryLibrary returns a library holding the given puzzles.
+func NewMemoryLibrary(puzzles ...Puzzle) *MemoryLibrary {
+	l := &MemoryLibrary{puzzles: map[libraryKey][]Puzzle{}}
+	for _, p := range puzzles {
+		l.Add(p)
+	}
+	return l
+}
+
+// Add stores a puzzle under its geometry and difficulty, filling in its
+// clue count and minimality.
+func (l *MemoryLibrary) Add(p Puzzle) {
+	p = finishPuzzle(context.Background(), p)
+	key := libraryKey{geo: p.Geometry, d: p.Difficulty}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.puzzles[key] = append(l.puzzles[key], p)
+}
+
+// Puzzles returns the stored puzzles for a geometry and difficulty.
+func (l *MemoryLibrary) Puzzles(g Geometry, d Difficulty) []Puzzle {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	list := l.puzzles[libraryKey{geo: g, d: d}]
+	return list[:len(list):len(list)]
+}
+
+// Generator produces puzzles from a random source and an optional library.
+// It is safe for concurrent use: the source is only touched under a lock,
+// to draw seeds and library picks, and every carve attempt runs on its own
+// seeded source.
+type Generator struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+	lib  Library
+}
+
+// NewGenerator returns a Generator drawing from src. lib may be nil.
+func NewGenerator(src rand.Source, lib Library) *Generator {
+	return &Generator{rand: rand.New(src), lib: lib}
+}
+
+// defaultGenerator backs the package-level Generate functions.
+var defaultGenerator = NewGenerator(rand.NewSource(time.Now().UnixNano()), nil)
+
+// seed draws a seed for a generation run.
+func (gen *Generator) seed() int64 {
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	return gen.rand.Int63()
+}
+
+// intn draws a random index below n.
+func (gen *Generator) intn(n int) int {
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	return gen.rand.Intn(n)
+}
+
+// Pick returns a random library puzzle for a geometry and difficulty whose
+// givens follow the symmetry.
+func (gen *Generator) Pick(g Geometry, d Difficulty, sym Symmetry) (Puzzle, bool) {
+	if gen.lib == nil {
+		return Puzzle{}, false
+	}
+	var matches []Puzzle
+	for _, p := range gen.lib.Puzzles(g, d) {
+		if sym.Matches(g, p.Givens) {
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) == 0 {
+		return Puzzle{}, false
+	}
+	p := matches[gen.intn(len(matches))]
+	p.Symmetry = sym
+	return p, true
+}
+
+// Generate serves a matching library puzzle when one exists and otherwise
+// carves a new one as GenerateWith describes. Requests with a Seed, an
+// exact clue count, Minimal, a variant, or rules other than the standard
+// ones always carve, so their options hold.
+func (gen *Generator) Generate(ctx context.Context, g Geometry, d Difficulty, opts Options) (Puzzle, error) {
+	classic := (opts.Rules == nil || opts.Rules.Classic()) && opts.Variant == nil
+	if opts.Seed == 0 && opts.Clues == 0 && !opts.Minimal && classic {
+		if p, ok := gen.Pick(g, d, opts.Symmetry); ok {
+			return p, nil
+		}
+	}
+	return gen.carve(ctx, g, d, opts)
+}
+
+// Solution builds a random full valid grid.
+func (gen *Generator) Solution(g Geometry) Board {
+	return generateSolution(rand.New(rand.NewSource(gen.seed())), g)
+}
@@ -0,0 +1,81 @@
+package sudoku
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// TestGeneratorConcurrent shares one Generator and its MemoryLibrary
+// between goroutines that pick, carve, add and list puzzles at once;
+// Minimal requests always carve. Run it with -race.
+func TestGeneratorConcurrent(t *testing.T) {
+	lib := NewMemoryLibrary()
+	gen := NewGenerator(rand.NewSource(1), lib)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(opts Options) {
+			defer wg.Done()
+			for i := 0; i < 4; i++ {
+				p, err := gen.Generate(context.Background(), Geometry6, Easy, opts)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if n, err := CountSolutions(Geometry6, p.Givens, 2); err != nil || n != 1 {
+					t.Errorf("generated puzzle has %d solutions (%v)", n, err)
+				}
+				lib.Add(p)
+				for _, q := range lib.Puzzles(Geometry6, Easy) {
+					if q.Givens == nil {
+						t.Error("library listed a puzzle without givens")
+					}
+				}
+				gen.Solution(Geometry4)
+			}
+		}(Options{Minimal: w%2 == 1})
+	}
+	wg.Wait()
+	if got := len(lib.Puzzles(Geometry6, Easy)); got != 32 {
+		t.Errorf("library holds %d puzzles, want 32", got)
+	}
+}
+
+// TestGenerateSeedReproducible checks that a seed gives the same puzzle
+// and ID whatever generator and worker count carve it, and that the ID
+// regenerates it.
+func TestGenerateSeedReproducible(t *testing.T) {
+	cases := []struct {
+		g    Geometry
+		d    Difficulty
+		opts Options
+	}{
+		{Geometry9, Medium, Options{Seed: 42}},
+		{Geometry8, Hard, Options{Seed: 7, Symmetry: SymmetryRotate180}},
+		{Geometry9, Easy, Options{Seed: 3, Variant: KillerVariant}},
+	}
+	for _, c := range cases {
+		first, err := NewGenerator(rand.NewSource(1), nil).Generate(context.Background(), c.g, c.d, c.opts)
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.g, c.d, err)
+		}
+		opts := c.opts
+		opts.Workers = 1
+		again, err := NewGenerator(rand.NewSource(2), nil).Generate(context.Background(), c.g, c.d, opts)
+		if err != nil {
+			t.Fatalf("%s %s on one worker: %v", c.g, c.d, err)
+		}
+		fromID, err := GenerateFromID(first.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", first.ID, err)
+		}
+		for _, p := range []Puzzle{again, fromID} {
+			if p.ID != first.ID || !slices.Equal(p.Givens, first.Givens) || !slices.Equal(p.Solution, first.Solution) {
+				t.Errorf("seed %d gave %s, then %s with other givens or solution", c.opts.Seed, first.ID, p.ID)
+			}
+		}
+	}
+}
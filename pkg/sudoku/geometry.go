@@ -0,0 +1,80 @@
+package sudoku
+
+import "fmt"
+
+// Geometry defines a board size and its box dimensions.
+type Geometry struct {
+	Size    int
+	BoxRows int
+	BoxCols int
+}
+
+// Standard geometries supported by the engine.
+var (
+	Geometry4  = Geometry{Size: 4, BoxRows: 2, BoxCols: 2}
+	Geometry6  = Geometry{Size: 6, BoxRows: 2, BoxCols: 3}
+	Geometry8  = Geometry{Size: 8, BoxRows: 2, BoxCols: 4}
+	Geometry9  = Geometry{Size: 9, BoxRows: 3, BoxCols: 3}
+	Geometry10 = Geometry{Size: 10, BoxRows: 2, BoxCols: 5}
+	Geometry12 = Geometry{Size: 12, BoxRows: 3, BoxCols: 4}
+	Geometry16 = Geometry{Size: 16, BoxRows: 4, BoxCols: 4}
+)
+
+// geometries is the catalog used by GeometryFor.
+var geometries = map[int]Geometry{
+	4:  Geometry4,
+	6:  Geometry6,
+	8:  Geometry8,
+	9:  Geometry9,
+	10: Geometry10,
+	12: Geometry12,
+	16: Geometry16,
+}
+
+// Geometries lists the standard geometries from smallest to largest.
+var Geometries = []Geometry{Geometry4, Geometry6, Geometry8, Geometry9, Geometry10, Geometry12, Geometry16}
+
+// GeometryFor returns the standard geometry for a board size.
+func GeometryFor(size int) (Geometry, error) {
+	g, ok := geometries[size]
+	if !ok {
+		return Geometry{}, fmt.Errorf("%w: no standard geometry for size %d", ErrInvalidGeometry, size)
+	}
+	return g, nil
+}
+
+// Validate checks that boxes tile the board and digits fit a uint16 mask.
+func (g Geometry) Validate() error {
+	if g.Size < 1 || g.Size > 16 {
+		return fmt.Errorf("%w: size %d out of range 1-16", ErrInvalidGeometry, g.Size)
+	}
+	if g.BoxRows < 1 || g.BoxCols < 1 || g.BoxRows*g.BoxCols != g.Size {
+		return fmt.Errorf("%w: %dx%d boxes do not tile size %d", ErrInvalidGeometry, g.BoxRows, g.BoxCols, g.Size)
+	}
+	return nil
+}
+
+// Cells returns the number of cells on the board.
+func (g Geometry) Cells() int {
+	return g.Size * g.Size
+}
+
+// Index returns the flat index for a row/col.
+func (g Geometry) Index(row, col int) int {
+	return row*g.Size + col
+}
+
+// Box returns the box number (row-major) containing a row/col.
+func (g Geometry) Box(row, col int) int {
+	return (row/g.BoxRows)*(g.Size/g.BoxCols) + col/g.BoxCols
+}
+
+// FullMask returns a candidate mask with every digit set.
+func (g Geometry) FullMask() uint16 {
+	return uint16(1<<uint(g.Size)) - 1
+}
+
+// String returns a short label such as "9x9 (3x3)".
+func (g Geometry) String() string {
+	return fmt.Sprintf("%dx%d (%dx%d)", g.Size, g.Size, g.BoxRows, g.BoxCols)
+}
@@ -0,0 +1,162 @@
+package sudoku
+
+// Grid tracks placed digits and pencil-mark candidates for logical solving.
+// Candidates only shrink: placing a digit removes it from every peer, and
+// steps from the technique engine remove further candidates.
+type Grid struct {
+	lay    *layout
+	state  *State
+	values Board
+	cands  []uint16
+}
+
+// NewGrid builds a candidate grid for the standard rules of a geometry.
+func NewGrid(g Geometry, b Board) (*Grid, error) {
+	r, err := StandardRules(g)
+	if err != nil {
+		return nil, err
+	}
+	return r.NewGrid(b)
+}
+
+// NewGrid builds a candidate grid from a board, filling every empty cell
+// with the digits its regions and constraints still allow.
+func (r *Rules) NewGrid(b Board) (*Grid, error) {
+	if err := r.Check(b); err != nil {
+		return nil, err
+	}
+	l := r.lay
+	st := newState(l, b)
+	gr := &Grid{
+		lay:    l,
+		state:  st,
+		values: st.values,
+		cands:  make([]uint16, len(b)),
+	}
+	for i := range gr.cands {
+		gr.cands[i] = st.Candidates(i)
+	}
+	return gr, nil
+}
+
+// Geometry returns the grid's geometry.
+func (gr *Grid) Geometry() Geometry {
+	return gr.lay.geo
+}
+
+// Value returns the digit placed at a cell, or 0.
+func (gr *Grid) Value(i int) uint8 {
+	return gr.values[i]
+}
+
+// Values returns a copy of the placed digits.
+func (gr *Grid) Values() Board {
+	return gr.values.Clone()
+}
+
+// Candidates returns the candidate mask of a cell (0 when filled).
+func (gr *Grid) Candidates(i int) uint16 {
+	return gr.cands[i]
+}
+
+// Clone returns an independent copy of the grid.
+func (gr *Grid) Clone() *Grid {
+	st := gr.state.Clone()
+	cands := make([]uint16, len(gr.cands))
+	copy(cands, gr.cands)
+	return &Grid{lay: gr.lay, state: st, values: st.values, cands: cands}
+}
+
+// Place fills a cell and removes the digit from its peers' candidates.
+// Under constraints beyond regions, every empty cell is narrowed to what
+// they still allow.
+func (gr *Grid) Place(i int, digit uint8) {
+	gr.state.Set(i, digit)
+	gr.cands[i] = 0
+	mask := uint16(1) << uint(digit-1)
+	for _, j := range gr.lay.peers[i] {
+		gr.cands[j] &^= mask
+	}
+	if len(gr.lay.pruners) == 0 {
+		return
+	}
+	for j, value := range gr.values {
+		if value == 0 {
+			gr.cands[j] &= gr.lay.allowed(gr.values, j)
+		}
+	}
+}
+
+// Eliminate removes a candidate from a cell and reports whether it was set.
+func (gr *Grid) Eliminate(i int, digit uint8) bool {
+	mask := uint16(1) << uint(digit-1)
+	if gr.cands[i]&mask == 0 {
+		return false
+	}
+	gr.cands[i] &^= mask
+	return true
+}
+
+// Apply performs a step's placements and eliminations.
+func (gr *Grid) Apply(step Step) {
+	for _, c := range step.Placements {
+		if gr.values[c.Cell] == 0 {
+			gr.Place(c.Cell, c.Digit)
+		}
+	}
+	for _, c := range step.Eliminations {
+		gr.Eliminate(c.Cell, c.Digit)
+	}
+}
+
+// Solved reports whether every cell is filled.
+func (gr *Grid) Solved() bool {
+	return gr.values.Complete()
+}
+
+// Broken reports an obvious contradiction: an empty cell without
+// candidates, or a house where a missing digit has no cell left.
+func (gr *Grid) Broken() bool {
+	for i, value := range gr.values {
+		if value == 0 && gr.cands[i] == 0 {
+			return true
+		}
+	}
+	full := gr.lay.geo.FullMask()
+	for _, cells := range gr.lay.unitCells {
+		covered := uint16(0)
+		for _, i := range cells {
+			if gr.values[i] != 0 {
+				covered |= 1 << uint(gr.values[i]-1)
+			} else {
+				covered |= gr.cands[i]
+			}
+		}
+		if covered != full {
+			return true
+		}
+	}
+	return false
+}
+
+// digitCells returns the empty cells of a unit that still allow a digit.
+func (gr *Grid) digitCells(u int, digit uint8) []int {
+	mask := uint16(1) << uint(digit-1)
+	var cells []int
+	for _, i := range gr.lay.unitCells[u] {
+		if gr.cands[i]&mask != 0 {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+// unitHas reports whether a digit is already placed in a unit.
+func (gr *Grid) unitHas(u int, digit uint8) bool {
+	for _, i := range gr.lay.unitCells[u] {
+		if gr.values[i] == digit {
+			return true
+		}
+	}
+	return false
+}
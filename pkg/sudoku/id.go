@@ -0,0 +1,145 @@
+package sudoku
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GeneratorVersion changes whenever the same seed and options would carve
+// or rate a different puzzle, so old IDs are rejected instead of misread.
+const GeneratorVersion = 3
+
+// PuzzleID identifies a generated puzzle by everything needed to carve it
+// again: size, difficulty, generator version, variant, symmetry, clue
+// target, and the seed of the attempt that produced it. Variant is the
+// variant's name, empty for classic puzzles.
+type PuzzleID struct {
+	Size       int
+	Difficulty Difficulty
+	Version    int
+	Variant    string
+	Symmetry   Symmetry
+	Clues      int
+	Seed       int64
+}
+
+// symmetryCodes are the short symmetry names used in IDs.
+var symmetryCodes = map[Symmetry]string{
+	SymmetryNone:             "none",
+	SymmetryRotate180:        "rot180",
+	SymmetryRotate90:         "rot90",
+	SymmetryMirrorHorizontal: "mirh",
+	SymmetryMirrorVertical:   "mirv",
+	SymmetryDiagonal:         "diag",
+}
+
+// String formats the ID, e.g. "9x9-hard-v3-rot180-c24-3dc03add". Variant
+// puzzles lead with the variant, as in "killer-9x9-medium-v3-none-c0-37024b2".
+func (id PuzzleID) String() string {
+	prefix := ""
+	if id.Variant != "" {
+		prefix = id.Variant + "-"
+	}
+	return prefix + fmt.Sprintf("%dx%d-%s-v%d-%s-c%d-%x",
+		id.Size, id.Size,
+		strings.ToLower(id.Difficulty.String()),
+		id.Version,
+		symmetryCodes[id.Symmetry],
+		id.Clues,
+		uint64(id.Seed),
+	)
+}
+
+// ParsePuzzleID parses an ID produced by PuzzleID.String.
+func ParsePuzzleID(value string) (PuzzleID, error) {
+	invalid := fmt.Errorf("%w: %q", ErrInvalidPuzzleID, value)
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(value)), "-")
+	var id PuzzleID
+	if len(parts) == 7 {
+		if _, err := VariantByName(parts[0]); err != nil {
+			return PuzzleID{}, invalid
+		}
+		id.Variant, parts = parts[0], parts[1:]
+	}
+	if len(parts) != 6 {
+		return PuzzleID{}, invalid
+	}
+	var rows, cols int
+	if _, err := fmt.Sscanf(parts[0], "%dx%d", &rows, &cols); err != nil || rows != cols {
+		return PuzzleID{}, invalid
+	}
+	id.Size = rows
+	d, err := ParseDifficulty(parts[1])
+	if err != nil {
+		return PuzzleID{}, invalid
+	}
+	id.Difficulty = d
+	if !strings.HasPrefix(parts[2], "v") || !strings.HasPrefix(parts[4], "c") {
+		return PuzzleID{}, invalid
+	}
+	if id.Version, err = strconv.Atoi(parts[2][1:]); err != nil {
+		return PuzzleID{}, invalid
+	}
+	found := false
+	for s, code := range symmetryCodes {
+		if code == parts[3] {
+			id.Symmetry, found = s, true
+		}
+	}
+	if !found {
+		return PuzzleID{}, invalid
+	}
+	if id.Clues, err = strconv.Atoi(parts[4][1:]); err != nil {
+		return PuzzleID{}, invalid
+	}
+	seed, err := strconv.ParseUint(parts[5], 16, 64)
+	if err != nil {
+		return PuzzleID{}, invalid
+	}
+	id.Seed = int64(seed)
+	return id, nil
+}
+
+// GenerateFromID carves the puzzle an ID describes. It fails with
+// ErrInvalidPuzzleID when the ID comes from another generator version,
+// names an unknown size or variant, or its difficulty does not match the rating of
+// the regenerated puzzle.
+func GenerateFromID(id PuzzleID) (Puzzle, error) {
+	if id.Version != GeneratorVersion {
+		return Puzzle{}, fmt.Errorf("%w: generator version %d, want %d", ErrInvalidPuzzleID, id.Version, GeneratorVersion)
+	}
+	g, err := GeometryFor(id.Size)
+	if err != nil {
+		return Puzzle{}, fmt.Errorf("%w: %w", ErrInvalidPuzzleID, err)
+	}
+	if id.Clues < 0 || id.Clues > g.Cells() {
+		return Puzzle{}, fmt.Errorf("%w: %d clues for %s", ErrInvalidPuzzleID, id.Clues, g)
+	}
+	var opts Options
+	if id.Variant != "" {
+		if opts.Variant, err = VariantByName(id.Variant); err != nil {
+			return Puzzle{}, fmt.Errorf("%w: %w", ErrInvalidPuzzleID, err)
+		}
+	}
+	p, ok := generateAttempt(context.Background(), g, id, opts)
+	if !ok {
+		return Puzzle{}, fmt.Errorf("%w: %s", ErrGenerationFailed, id)
+	}
+	if p.Difficulty != id.Difficulty {
+		return Puzzle{}, fmt.Errorf("%w: %s rates %s", ErrInvalidPuzzleID, id, p.Difficulty)
+	}
+	return finishPuzzle(context.Background(), p), nil
+}
+
+// attemptSeed derives the seed of the n-th carve attempt from a generation
+// seed with a SplitMix64 step. The result is kept below 2^31-1, the range
+// math/rand sources actually use, which also keeps IDs short.
+func attemptSeed(seed, n int64) int64 {
+	z := uint64(seed) + uint64(n+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return int64(z%(1<<31-2)) + 1
+}
@@ -0,0 +1,85 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestPuzzleIDRoundTrip checks that IDs format and parse back to the same
+// fields.
+func TestPuzzleIDRoundTrip(t *testing.T) {
+	cases := []struct {
+		id   PuzzleID
+		text string
+	}{
+		{PuzzleID{Size: 9, Difficulty: Hard, Version: 2, Symmetry: SymmetryRotate180, Clues: 24, Seed: 0x3dc03add}, "9x9-hard-v2-rot180-c24-3dc03add"},
+		{PuzzleID{Size: 16, Difficulty: Diabolical, Version: 2, Symmetry: SymmetryDiagonal, Clues: 98, Seed: 1}, "16x16-diabolical-v2-diag-c98-1"},
+		{PuzzleID{Size: 9, Difficulty: Medium, Version: 2, Variant: "killer", Clues: 0, Seed: 0x37024b2}, "killer-9x9-medium-v2-none-c0-37024b2"},
+	}
+	for _, c := range cases {
+		if got := c.id.String(); got != c.text {
+			t.Errorf("%+v formats as %q, want %q", c.id, got, c.text)
+		}
+		got, err := ParsePuzzleID(c.text)
+		if err != nil || got != c.id {
+			t.Errorf("ParsePuzzleID(%q) = %+v, %v, want %+v", c.text, got, err, c.id)
+		}
+	}
+	if got, err := ParsePuzzleID(" 9X9-HARD-V2-ROT180-C24-3DC03ADD "); err != nil || got != cases[0].id {
+		t.Errorf("upper case with spaces: %+v, %v", got, err)
+	}
+}
+
+// TestParsePuzzleIDInvalid checks that malformed IDs are rejected.
+func TestParsePuzzleIDInvalid(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"9x9-hard-v2-rot180-c24",
+		"9x8-hard-v2-rot180-c24-1",
+		"9x9-tricky-v2-rot180-c24-1",
+		"9x9-hard-2-rot180-c24-1",
+		"9x9-hard-v2-spiral-c24-1",
+		"9x9-hard-v2-rot180-24-1",
+		"9x9-hard-v2-rot180-c24-xyz",
+		"sandwich-9x9-hard-v2-none-c24-1",
+	} {
+		if _, err := ParsePuzzleID(text); !errors.Is(err, ErrInvalidPuzzleID) {
+			t.Errorf("ParsePuzzleID(%q) error %v, want ErrInvalidPuzzleID", text, err)
+		}
+	}
+}
+
+// TestGenerateFromID checks that a generated ID survives formatting and
+// carves the same puzzle again, and that stale or mislabelled IDs fail.
+func TestGenerateFromID(t *testing.T) {
+	for _, opts := range []Options{{Seed: 5}, {Seed: 6, Symmetry: SymmetryRotate90}, {Seed: 7, Variant: KillerVariant}} {
+		p, err := GenerateWith(context.Background(), Geometry6, Easy, opts)
+		if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+			t.Fatal(err)
+		}
+		id, err := ParsePuzzleID(p.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := GenerateFromID(id)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if again.ID != p.ID || !slices.Equal(again.Givens, p.Givens) || !slices.Equal(again.Solution, p.Solution) {
+			t.Errorf("%s carved a different puzzle", id)
+		}
+	}
+
+	id := fixedPuzzle(t, Geometry6, Easy).ID
+	stale := id
+	stale.Version = 1
+	wrong := id
+	wrong.Difficulty = Evil
+	for _, bad := range []PuzzleID{stale, wrong, {Size: 7, Version: GeneratorVersion}} {
+		if _, err := GenerateFromID(bad); !errors.Is(err, ErrInvalidPuzzleID) {
+			t.Errorf("GenerateFromID(%s) error %v, want ErrInvalidPuzzleID", bad, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// JigsawBoxes turns a box map, the index of the box holding each cell,
+// into the irregular boxes of Jigsaw Sudoku. Every box must be an
+// orthogonally connected group of Size cells. The boxes are houses of
+// kind BoxUnit, so they take the place of Boxes alongside Rows and
+// Columns.
+func JigsawBoxes(g Geometry, boxOf []int) (RegionSet, error) {
+	if len(boxOf) != g.Cells() {
+		return nil, fmt.Errorf("%w: box map has %d cells, want %d", ErrInvalidRules, len(boxOf), g.Cells())
+	}
+	boxes := make([][]int, g.Size)
+	for i, box := range boxOf {
+		if box < 0 || box >= g.Size {
+			return nil, fmt.Errorf("%w: cell %d is in box %d", ErrInvalidRules, i, box+1)
+		}
+		boxes[box] = append(boxes[box], i)
+	}
+	set := make(RegionSet, g.Size)
+	for box, cells := range boxes {
+		if len(cells) != g.Size {
+			return nil, fmt.Errorf("%w: box %d has %d cells", ErrInvalidRules, box+1, len(cells))
+		}
+		if !connected(g, boxOf, cells) {
+			return nil, fmt.Errorf("%w: box %d is not connected", ErrInvalidRules, box+1)
+		}
+		set[box] = Region{Unit: Unit{Kind: BoxUnit, Index: box}, Cells: cells}
+	}
+	return set, nil
+}
+
+// BoxMap returns the index of the box holding each cell, or -1 for cells
+// in none, whether the boxes are the classic rectangles or a jigsaw.
+func (r *Rules) BoxMap() []int {
+	boxOf := make([]int, r.lay.geo.Cells())
+	for i := range boxOf {
+		boxOf[i] = -1
+	}
+	for _, region := range r.lay.regions {
+		if region.Unit.Kind != BoxUnit {
+			continue
+		}
+		for _, i := range region.Cells {
+			boxOf[i] = region.Unit.Index
+		}
+	}
+	return boxOf
+}
+
+// connected reports whether cells, all in one box of boxOf, form an
+// orthogonally connected group.
+func connected(g Geometry, boxOf []int, cells []int) bool {
+	box := boxOf[cells[0]]
+	seen := map[int]bool{cells[0]: true}
+	queue := []int{cells[0]}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for _, next := range neighbours(g, cell) {
+			if boxOf[next] == box && !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return len(seen) == len(cells)
+}
+
+// jigsawVariant is Jigsaw Sudoku: rows and columns plus irregular boxes
+// reshaped around the solution.
+type jigsawVariant struct{}
+
+// JigsawVariant generates Jigsaw Sudoku puzzles.
+var JigsawVariant = registerVariant(jigsawVariant{})
+
+// jigsawTrades is how many trades per cell reshaping tries.
+const jigsawTrades = 64
+
+// Name returns "jigsaw".
+func (jigsawVariant) Name() string {
+	return "jigsaw"
+}
+
+// Base returns the standard rules with the boxes as a plain region set,
+// so the solution is filled at random rather than from the classic
+// pattern, whose regular layout leaves boxes few digits to trade.
+func (jigsawVariant) Base(g Geometry, r *rand.Rand) (*Rules, error) {
+	return NewRules(g, Rows, Columns, RegionSet(Boxes.Regions(g)))
+}
+
+// Decorate replaces the classic boxes with irregular ones that the
+// solution still fills with every digit once.
+func (jigsawVariant) Decorate(base *Rules, solution Board, r *rand.Rand) (*Rules, error) {
+	g := base.Geometry()
+	boxOf := base.BoxMap()
+	reshapeBoxes(r, g, boxOf, solution)
+	boxes, err := JigsawBoxes(g, boxOf)
+	if err != nil {
+		return nil, err
+	}
+	return NewRules(g, Rows, Columns, boxes)
+}
+
+// ClueTarget uses the classic targets.
+func (jigsawVariant) ClueTarget(size int, d Difficulty) int {
+	return ClueTarget(size, d)
+}
+
+// reshapeBoxes has neighbouring boxes of boxOf trade cells holding the
+// same solution digit, so every box keeps one of each digit. A trade is
+// kept when both boxes stay connected.
+func reshapeBoxes(r *rand.Rand, g Geometry, boxOf []int, solution Board) {
+	for n := 0; n < jigsawTrades*g.Cells(); n++ {
+		a := r.Intn(g.Cells())
+		var others []int
+		for _, next := range neighbours(g, a) {
+			if boxOf[next] != boxOf[a] {
+				others = append(others, boxOf[next])
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		from, to := boxOf[a], others[r.Intn(len(others))]
+		b := boxCell(boxOf, solution, to, solution[a])
+		if b < 0 || !touches(g, boxOf, b, from) {
+			continue
+		}
+		boxOf[a], boxOf[b] = to, from
+		if !connected(g, boxOf, boxCells(boxOf, from)) || !connected(g, boxOf, boxCells(boxOf, to)) {
+			boxOf[a], boxOf[b] = from, to
+		}
+	}
+}
+
+// boxCell returns the cell of a box whose solution digit is digit, or -1.
+func boxCell(boxOf []int, solution Board, box int, digit uint8) int {
+	for i, b := range boxOf {
+		if b == box && solution[i] == digit {
+			return i
+		}
+	}
+	return -1
+}
+
+// boxCells lists the cells of a box.
+func boxCells(boxOf []int, box int) []int {
+	var cells []int
+	for i, b := range boxOf {
+		if b == box {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+// touches reports whether a cell borders a box.
+func touches(g Geometry, boxOf []int, cell, box int) bool {
+	for _, next := range neighbours(g, cell) {
+		if boxOf[next] == box {
+			return true
+		}
+	}
+	return false
+}
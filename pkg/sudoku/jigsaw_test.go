@@ -0,0 +1,67 @@
+package sudoku
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// TestJigsawBoxes checks which box maps JigsawBoxes accepts.
+func TestJigsawBoxes(t *testing.T) {
+	cases := []struct {
+		name  string
+		boxOf []int
+		ok    bool
+	}{
+		{"classic", []int{0, 0, 1, 1, 0, 0, 1, 1, 2, 2, 3, 3, 2, 2, 3, 3}, true},
+		{"bands", []int{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3}, true},
+		{"short map", []int{0, 0, 1, 1}, false},
+		{"box off board", []int{0, 0, 1, 1, 0, 0, 1, 1, 2, 2, 3, 3, 2, 2, 3, 4}, false},
+		{"uneven boxes", []int{0, 0, 1, 1, 0, 0, 1, 1, 2, 2, 3, 3, 2, 2, 3, 0}, false},
+		{"split box", []int{0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 3, 3, 2, 2, 3, 3}, false},
+	}
+	for _, c := range cases {
+		boxes, err := JigsawBoxes(Geometry4, c.boxOf)
+		if c.ok && err != nil || !c.ok && !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("%s: error %v", c.name, err)
+			continue
+		}
+		if !c.ok {
+			continue
+		}
+		r, err := NewRules(Geometry4, Rows, Columns, boxes)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got := r.BoxMap(); !slices.Equal(got, c.boxOf) {
+			t.Errorf("%s: box map %v", c.name, got)
+		}
+	}
+}
+
+// TestJigsawDecorate checks that reshaped boxes stay connected, hold each
+// solution digit once and keep the solution valid.
+func TestJigsawDecorate(t *testing.T) {
+	for _, g := range []Geometry{Geometry6, Geometry9} {
+		solution := fixedPuzzle(t, g, Easy).Solution
+		base, err := JigsawVariant.Base(g, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := JigsawVariant.Decorate(base, solution, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatalf("%s: %v", g, err)
+		}
+		boxOf := r.BoxMap()
+		if slices.Equal(boxOf, base.BoxMap()) {
+			t.Errorf("%s: boxes were not reshaped", g)
+		}
+		if _, err := JigsawBoxes(g, boxOf); err != nil {
+			t.Errorf("%s: reshaped boxes: %v", g, err)
+		}
+		if err := r.Check(solution); err != nil {
+			t.Errorf("%s: solution breaks the reshaped boxes: %v", g, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestNewKiller checks which cages NewKiller accepts.
+func TestNewKiller(t *testing.T) {
+	cases := []struct {
+		name  string
+		cages []Cage
+		ok    bool
+	}{
+		{"pair", []Cage{{Cells: []int{0, 1}, Sum: 3}}, true},
+		{"empty cage", []Cage{{Sum: 3}}, false},
+		{"sum too low", []Cage{{Cells: []int{0, 1}, Sum: 2}}, false},
+		{"sum too high", []Cage{{Cells: []int{0, 1}, Sum: 18}}, false},
+		{"shared cell", []Cage{{Cells: []int{0, 1}, Sum: 3}, {Cells: []int{1, 2}, Sum: 3}}, false},
+		{"cell off board", []Cage{{Cells: []int{80, 81}, Sum: 3}}, false},
+	}
+	for _, c := range cases {
+		_, err := NewKiller(Geometry9, c.cages)
+		if c.ok && err != nil || !c.ok && !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("%s: error %v", c.name, err)
+		}
+	}
+}
+
+// TestKillerAllowed checks the digits a cage leaves a cell as its other
+// cells fill up.
+func TestKillerAllowed(t *testing.T) {
+	k, err := NewKiller(Geometry9, []Cage{
+		{Cells: []int{0, 1}, Sum: 3},
+		{Cells: []int{2, 3, 4}, Sum: 24},
+		{Cells: []int{5, 6}, Sum: 10},
+		{Cells: []int{9, 10, 11}, Sum: 12},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		filled map[int]uint8
+		cell   int
+		want   string
+	}{
+		{"3 in two", nil, 0, "12"},
+		{"3 with a 1", map[int]uint8{1: 1}, 0, "2"},
+		{"24 in three", nil, 2, "789"},
+		{"24 with a 9", map[int]uint8{3: 9}, 2, "78"},
+		{"10 with a 5", map[int]uint8{6: 5}, 5, ""},
+		{"10 with a 3", map[int]uint8{6: 3}, 5, "7"},
+		// Allowed only bounds the sum, so a 2 passes though it needs another.
+		{"12 with an 8", map[int]uint8{9: 8}, 10, "123"},
+		{"no cage", nil, 40, "123456789"},
+	}
+	for _, c := range cases {
+		b := make(Board, Geometry9.Cells())
+		for i, v := range c.filled {
+			b[i] = v
+		}
+		if got, want := k.Allowed(Geometry9, b, c.cell), digitMask(c.want); got != want {
+			t.Errorf("%s: allowed %09b, want %09b", c.name, got, want)
+		}
+	}
+}
+
+// TestKillerNarrow checks that cages keep the digits of the combinations
+// every empty cell can join, pooled across the cage, and report cages with
+// none.
+func TestKillerNarrow(t *testing.T) {
+	cases := []struct {
+		name  string
+		cage  Cage
+		cands map[int]string
+		want  map[int]string
+		ok    bool
+	}{
+		{"4 in two", Cage{Cells: []int{0, 1}, Sum: 4}, nil, map[int]string{0: "13", 1: "13"}, true},
+		{"6 in three", Cage{Cells: []int{0, 1, 2}, Sum: 6}, map[int]string{0: "1"}, map[int]string{0: "1", 1: "123", 2: "123"}, true},
+		{"10 with a low cell", Cage{Cells: []int{0, 1}, Sum: 10}, map[int]string{0: "12"}, map[int]string{0: "12", 1: "1289"}, true},
+		{"17 with low cells", Cage{Cells: []int{0, 1}, Sum: 17}, map[int]string{0: "12"}, nil, false},
+	}
+	for _, c := range cases {
+		k, err := NewKiller(Geometry9, []Cage{c.cage})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cands := make([]uint16, Geometry9.Cells())
+		for i := range cands {
+			cands[i] = Geometry9.FullMask()
+		}
+		for i, digits := range c.cands {
+			cands[i] = digitMask(digits)
+		}
+		ok := k.narrow(Geometry9, make(Board, Geometry9.Cells()), cands)
+		if ok != c.ok {
+			t.Errorf("%s: narrow reported %v", c.name, ok)
+			continue
+		}
+		for i, digits := range c.want {
+			if cands[i] != digitMask(digits) {
+				t.Errorf("%s: cell %d keeps %09b, want %s", c.name, i, cands[i], digits)
+			}
+		}
+	}
+}
+
+// TestKillerClueTarget checks the share of classic givens Killer keeps and
+// the floor on the largest boards.
+func TestKillerClueTarget(t *testing.T) {
+	cases := []struct {
+		size int
+		d    Difficulty
+		want int
+	}{
+		{9, Easy, 14},
+		{9, Medium, 4},
+		{9, Hard, 2},
+		{9, Diabolical, 2},
+		{12, Evil, 16},
+		{16, Easy, 56},
+	}
+	for _, c := range cases {
+		if got := KillerVariant.ClueTarget(c.size, c.d); got != c.want {
+			t.Errorf("ClueTarget(%d, %s) = %d, want %d", c.size, c.d, got, c.want)
+		}
+	}
+}
+
+// TestCountStopsWithContext checks that both counters give up soon after
+// ctx is done, even mid-search, so carving keeps to its deadline.
+func TestCountStopsWithContext(t *testing.T) {
+	killer, err := KillerVariant.Decorate(mustStandard(t, Geometry9), fixedPuzzle(t, Geometry9, Easy).Solution, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := make(Board, Geometry9.Cells())
+	for _, c := range []struct {
+		name string
+		l    *layout
+	}{{"dlx", mustStandard(t, Geometry9).lay}, {"backtrack", killer.lay}} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		countSolutionsContext(ctx, empty, c.l, 1<<30)
+		cancel()
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: counted for %v after a 50ms deadline", c.name, elapsed)
+		}
+	}
+}
+
+// mustStandard returns the standard rules of a geometry.
+func mustStandard(tb testing.TB, g Geometry) *Rules {
+	tb.Helper()
+	r, err := StandardRules(g)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return r
+}
@@ -0,0 +1,321 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// DotKind tells what a Kropki dot says about the digits it joins.
+type DotKind int
+
+const (
+	// WhiteDot joins consecutive digits.
+	WhiteDot DotKind = iota
+	// BlackDot joins a digit and its double.
+	BlackDot
+)
+
+// String returns "white" or "black".
+func (k DotKind) String() string {
+	if k == BlackDot {
+		return "black"
+	}
+	return "white"
+}
+
+// Dot is a Kropki dot on the edge between two orthogonally neighbouring
+// cells.
+type Dot struct {
+	Cells [2]int
+	Kind  DotKind
+}
+
+// Kropki is the dot rule of Kropki Sudoku. Build it with NewKropki.
+type Kropki struct {
+	dots     []Dot
+	dotOf    map[[2]int]int
+	negative bool
+	links    [][]dotLink
+	fits     [3][]uint16
+}
+
+// noDot marks a link between neighbours without a dot under the negative
+// rule.
+const noDot DotKind = 2
+
+// dotLink is an edge from a cell to a neighbour the rule says something
+// about, and the kind of dot on it.
+type dotLink struct {
+	cell int
+	kind DotKind
+}
+
+// NewKropki checks dots against a geometry: both cells on the board and
+// orthogonal neighbours, and at most one dot per edge. With negative set
+// every dot is given, so neighbours without a dot are neither consecutive
+// nor a digit and its double.
+func NewKropki(g Geometry, dots []Dot, negative bool) (*Kropki, error) {
+	k := &Kropki{dots: dots, dotOf: map[[2]int]int{}, negative: negative, links: make([][]dotLink, g.Cells())}
+	for n, dot := range dots {
+		a, b := dot.Cells[0], dot.Cells[1]
+		if a < 0 || a >= g.Cells() || !containsInt(neighbours(g, a), b) {
+			return nil, fmt.Errorf("%w: dot %d joins cells %d and %d", ErrInvalidRules, n+1, a, b)
+		}
+		if dot.Kind != WhiteDot && dot.Kind != BlackDot {
+			return nil, fmt.Errorf("%w: dot %d has kind %d", ErrInvalidRules, n+1, dot.Kind)
+		}
+		edge := edgeKey(a, b)
+		if _, ok := k.dotOf[edge]; ok {
+			return nil, fmt.Errorf("%w: dot %d repeats the edge of cells %d and %d", ErrInvalidRules, n+1, a, b)
+		}
+		k.dotOf[edge] = n
+	}
+	for cell := range k.links {
+		for _, n := range neighbours(g, cell) {
+			if dot, ok := k.DotBetween(cell, n); ok {
+				k.links[cell] = append(k.links[cell], dotLink{cell: n, kind: dot.Kind})
+			} else if negative {
+				k.links[cell] = append(k.links[cell], dotLink{cell: n, kind: noDot})
+			}
+		}
+	}
+	for kind := range k.fits {
+		k.fits[kind] = make([]uint16, g.Size+1)
+		for d := 1; d <= g.Size; d++ {
+			k.fits[kind][d] = dotPartners(g, DotKind(kind), uint8(d))
+		}
+	}
+	return k, nil
+}
+
+// edgeKey names the edge between two cells, whichever order they come in.
+func edgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// Dots returns the dots. The slice is shared and must not be modified.
+func (k *Kropki) Dots() []Dot {
+	return k.dots
+}
+
+// Negative reports whether every dot is given.
+func (k *Kropki) Negative() bool {
+	return k.negative
+}
+
+// DotBetween returns the dot on the edge between two cells, if any.
+func (k *Kropki) DotBetween(a, b int) (Dot, bool) {
+	n, ok := k.dotOf[edgeKey(a, b)]
+	if !ok {
+		return Dot{}, false
+	}
+	return k.dots[n], true
+}
+
+// Regions returns none; dots only join cells that already share a row or
+// column.
+func (k *Kropki) Regions(g Geometry) []Region {
+	return nil
+}
+
+// Allowed permits the digits that agree with the dot, or under the
+// negative rule the missing dot, on each edge to a filled neighbour.
+func (k *Kropki) Allowed(g Geometry, b Board, cell int) uint16 {
+	mask := g.FullMask()
+	for _, link := range k.links[cell] {
+		if value := b[link.cell]; value != 0 {
+			mask &= k.fits[link.kind][value]
+		}
+	}
+	return mask
+}
+
+// dotPartners returns the digits a dot of a kind lets sit next to digit;
+// for noDot, those that are neither consecutive nor its double or half.
+func dotPartners(g Geometry, kind DotKind, digit uint8) uint16 {
+	d := int(digit)
+	switch kind {
+	case WhiteDot:
+		mask := uint16(1) << uint(d)
+		if d > 1 {
+			mask |= 1 << uint(d-2)
+		}
+		return mask & g.FullMask()
+	case BlackDot:
+		mask := uint16(1) << uint(2*d-1)
+		if d%2 == 0 {
+			mask |= 1 << uint(d/2-1)
+		}
+		return mask & g.FullMask()
+	default:
+		return g.FullMask() &^ (dotPartners(g, WhiteDot, digit) | dotPartners(g, BlackDot, digit))
+	}
+}
+
+// cellCands returns the candidates of a cell, or its digit when filled.
+func cellCands(b Board, cands []uint16, cell int) uint16 {
+	if b[cell] != 0 {
+		return 1 << uint(b[cell]-1)
+	}
+	return cands[cell]
+}
+
+// support returns the digits of from that some digit of to fits across
+// a link of a kind.
+func (k *Kropki) support(kind DotKind, from, to uint16) uint16 {
+	var keep uint16
+	for d, fit := range k.fits[kind] {
+		if d > 0 && from&(1<<uint(d-1)) != 0 && to&fit != 0 {
+			keep |= 1 << uint(d-1)
+		}
+	}
+	return keep
+}
+
+// narrow keeps, across every link, only the candidates with a fitting
+// digit left on the other side.
+func (k *Kropki) narrow(g Geometry, b Board, cands []uint16) bool {
+	for a, links := range k.links {
+		if b[a] != 0 {
+			continue
+		}
+		for _, link := range links {
+			cands[a] = k.support(link.kind, cands[a], cellCands(b, cands, link.cell))
+		}
+		if cands[a] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// findKropkiDot removes candidates left without a fitting digit in a
+// neighbouring cell: across a dot, no partner of its kind; under the
+// negative rule, nothing but partners.
+func findKropkiDot(gr *Grid) (Step, bool) {
+	k := gr.lay.kropkiOf()
+	if k == nil {
+		return Step{}, false
+	}
+	for a, links := range k.links {
+		if gr.values[a] != 0 {
+			continue
+		}
+		for _, link := range links {
+			keep := k.support(link.kind, gr.cands[a], cellCands(gr.values, gr.cands, link.cell))
+			if keep == gr.cands[a] {
+				continue
+			}
+			var elims []Candidate
+			for _, d := range maskToValues(gr.cands[a]&^keep, gr.lay.geo.Size) {
+				elims = append(elims, Candidate{Cell: a, Digit: uint8(d)})
+			}
+			return Step{Technique: KropkiDot, Cells: []int{link.cell, a}, Eliminations: elims}, true
+		}
+	}
+	return Step{}, false
+}
+
+// kropkiOf returns the layout's dot rule, if it has one.
+func (l *layout) kropkiOf() *Kropki {
+	for _, c := range l.constraints {
+		if k, ok := c.(*Kropki); ok {
+			return k
+		}
+	}
+	return nil
+}
+
+// kropkiVariant is Kropki Sudoku: the classic rules plus dots read off
+// the solution. Some puzzles give every dot under the negative rule, so
+// neighbours without a dot are neither consecutive nor a digit and its
+// double; the others give only some of the dots and say nothing about
+// the edges without one.
+type kropkiVariant struct{}
+
+// KropkiVariant generates Kropki Sudoku puzzles.
+var KropkiVariant = registerVariant(kropkiVariant{})
+
+// kropkiNegativeChance is the chance that a generated puzzle gives every
+// dot under the negative rule.
+const kropkiNegativeChance = 0.5
+
+// kropkiDotShare is the share of the solution's dots a puzzle without the
+// negative rule gives.
+const kropkiDotShare = 0.7
+
+// kropkiClueShare is the share of a classic puzzle's givens a Kropki
+// puzzle keeps, per difficulty; the dots carry the rest.
+var kropkiClueShare = []float64{0.6, 0.45, 0.35, 0.3, 0.25, 0.25}
+
+// Name returns "kropki".
+func (kropkiVariant) Name() string {
+	return "kropki"
+}
+
+// Base returns the standard rules.
+func (kropkiVariant) Base(g Geometry, r *rand.Rand) (*Rules, error) {
+	return StandardRules(g)
+}
+
+// Decorate adds the dots the solution calls for: every one under the
+// negative rule, as r picks kropkiNegativeChance of the time, or else a
+// random kropkiDotShare of them.
+func (kropkiVariant) Decorate(base *Rules, solution Board, r *rand.Rand) (*Rules, error) {
+	g := base.Geometry()
+	dots := solutionDots(g, solution)
+	negative := r.Float64() < kropkiNegativeChance
+	if !negative {
+		dots = someDots(r, dots, kropkiDotShare)
+	}
+	k, err := NewKropki(g, dots, negative)
+	if err != nil {
+		return nil, err
+	}
+	constraints := append(append([]Constraint(nil), base.Constraints()...), k)
+	return NewRules(g, constraints...)
+}
+
+// ClueTarget keeps a share of the classic target that shrinks with
+// difficulty.
+func (kropkiVariant) ClueTarget(size int, d Difficulty) int {
+	if d < Easy || int(d) >= len(kropkiClueShare) {
+		d = Hard
+	}
+	return int(float64(ClueTarget(size, d)) * kropkiClueShare[d])
+}
+
+// solutionDots puts a white dot between every pair of consecutive
+// neighbours of the solution and a black one between every digit and
+// its double; 1 and 2, which are both, get a white dot.
+func solutionDots(g Geometry, solution Board) []Dot {
+	var dots []Dot
+	for a := range solution {
+		for _, c := range neighbours(g, a) {
+			if c < a {
+				continue
+			}
+			switch bit := uint16(1) << uint(solution[c]-1); {
+			case dotPartners(g, WhiteDot, solution[a])&bit != 0:
+				dots = append(dots, Dot{Cells: [2]int{a, c}, Kind: WhiteDot})
+			case dotPartners(g, BlackDot, solution[a])&bit != 0:
+				dots = append(dots, Dot{Cells: [2]int{a, c}, Kind: BlackDot})
+			}
+		}
+	}
+	return dots
+}
+
+// someDots keeps each dot with the chance share, in order.
+func someDots(r *rand.Rand, dots []Dot, share float64) []Dot {
+	var kept []Dot
+	for _, dot := range dots {
+		if r.Float64() < share {
+			kept = append(kept, dot)
+		}
+	}
+	return kept
+}
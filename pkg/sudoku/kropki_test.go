@@ -0,0 +1,131 @@
+package sudoku
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// TestNewKropki checks which dots NewKropki accepts.
+func TestNewKropki(t *testing.T) {
+	cases := []struct {
+		name string
+		dots []Dot
+		ok   bool
+	}{
+		{"row pair", []Dot{{Cells: [2]int{0, 1}, Kind: WhiteDot}}, true},
+		{"column pair", []Dot{{Cells: [2]int{9, 0}, Kind: BlackDot}}, true},
+		{"apart", []Dot{{Cells: [2]int{0, 2}}}, false},
+		{"across rows", []Dot{{Cells: [2]int{8, 9}}}, false},
+		{"off board", []Dot{{Cells: [2]int{-1, 0}}}, false},
+		{"unknown kind", []Dot{{Cells: [2]int{0, 1}, Kind: noDot}}, false},
+		{"repeated edge", []Dot{{Cells: [2]int{0, 1}}, {Cells: [2]int{1, 0}, Kind: BlackDot}}, false},
+	}
+	for _, c := range cases {
+		_, err := NewKropki(Geometry9, c.dots, false)
+		if c.ok && err != nil || !c.ok && !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("%s: error %v", c.name, err)
+		}
+	}
+}
+
+// TestKropkiAllowed checks the digits a dot, or a missing dot under the
+// negative rule, leaves a cell next to a filled neighbour.
+func TestKropkiAllowed(t *testing.T) {
+	dots := []Dot{{Cells: [2]int{0, 1}, Kind: WhiteDot}, {Cells: [2]int{9, 10}, Kind: BlackDot}}
+	cases := []struct {
+		name     string
+		negative bool
+		filled   map[int]uint8
+		cell     int
+		want     string
+	}{
+		{"white by a 5", false, map[int]uint8{1: 5}, 0, "46"},
+		{"white by a 9", false, map[int]uint8{1: 9}, 0, "8"},
+		{"black by a 4", false, map[int]uint8{10: 4}, 9, "28"},
+		{"black by a 3", false, map[int]uint8{10: 3}, 9, "6"},
+		{"black by a 5", false, map[int]uint8{10: 5}, 9, ""},
+		{"no dot", false, map[int]uint8{1: 4}, 2, "123456789"},
+		{"no dot, negative", true, map[int]uint8{1: 4}, 2, "14679"},
+	}
+	for _, c := range cases {
+		k, err := NewKropki(Geometry9, dots, c.negative)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := make(Board, Geometry9.Cells())
+		for i, v := range c.filled {
+			b[i] = v
+		}
+		if got, want := k.Allowed(Geometry9, b, c.cell), digitMask(c.want); got != want {
+			t.Errorf("%s: allowed %09b, want %09b", c.name, got, want)
+		}
+	}
+}
+
+// TestKropkiNarrow checks that every link keeps only the candidates with
+// a fitting digit across it and reports cells left with none.
+func TestKropkiNarrow(t *testing.T) {
+	cases := []struct {
+		name     string
+		dots     []Dot
+		negative bool
+		cands    map[int]string
+		want     map[int]string
+		ok       bool
+	}{
+		{"white by 1 or 9", []Dot{{Cells: [2]int{0, 1}}}, false, map[int]string{1: "19"}, map[int]string{0: "28"}, true},
+		{"black by 3 or 5", []Dot{{Cells: [2]int{0, 1}, Kind: BlackDot}}, false, map[int]string{1: "35"}, map[int]string{0: "6"}, true},
+		{"no dot by a 2", nil, false, map[int]string{1: "2"}, map[int]string{0: "123456789"}, true},
+		{"no dot by a 2, negative", nil, true, map[int]string{1: "2"}, map[int]string{0: "256789"}, true},
+		{"white between 1 and 5", []Dot{{Cells: [2]int{0, 1}}}, false, map[int]string{0: "1", 1: "5"}, nil, false},
+	}
+	for _, c := range cases {
+		k, err := NewKropki(Geometry9, c.dots, c.negative)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cands := make([]uint16, Geometry9.Cells())
+		for i := range cands {
+			cands[i] = Geometry9.FullMask()
+		}
+		for i, digits := range c.cands {
+			cands[i] = digitMask(digits)
+		}
+		ok := k.narrow(Geometry9, make(Board, Geometry9.Cells()), cands)
+		if ok != c.ok {
+			t.Errorf("%s: narrow reported %v", c.name, ok)
+			continue
+		}
+		for i, digits := range c.want {
+			if cands[i] != digitMask(digits) {
+				t.Errorf("%s: cell %d keeps %09b, want %s", c.name, i, cands[i], digits)
+			}
+		}
+	}
+}
+
+// TestKropkiDecorate checks that seeds pick both negative puzzles, with
+// every dot the solution calls for, and puzzles with only some of them.
+func TestKropkiDecorate(t *testing.T) {
+	solution := fixedPuzzle(t, Geometry9, Easy).Solution
+	all := len(solutionDots(Geometry9, solution))
+	seen := map[bool]bool{}
+	for seed := int64(1); seed <= 20; seed++ {
+		r, err := KropkiVariant.Decorate(mustStandard(t, Geometry9), solution, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		k := r.lay.kropkiOf()
+		seen[k.Negative()] = true
+		if k.Negative() && len(k.Dots()) != all || !k.Negative() && len(k.Dots()) >= all {
+			t.Errorf("seed %d: negative %v with %d of %d dots", seed, k.Negative(), len(k.Dots()), all)
+		}
+		if err := r.Check(solution); err != nil {
+			t.Errorf("seed %d: solution breaks the dots: %v", seed, err)
+		}
+	}
+	if !seen[true] || !seen[false] {
+		t.Errorf("20 seeds gave only negative %v puzzles", seen[true])
+	}
+}
@@ -0,0 +1,43 @@
+package sudoku
+
+import "context"
+
+// IsMinimal reports whether a puzzle has a unique solution under the
+// standard rules and loses it when any single clue is removed.
+func IsMinimal(g Geometry, b Board) (bool, error) {
+	r, err := StandardRules(g)
+	if err != nil {
+		return false, err
+	}
+	return r.IsMinimal(b)
+}
+
+// IsMinimal reports whether a puzzle has a unique solution under the rules
+// and loses it when any single clue is removed.
+func (r *Rules) IsMinimal(b Board) (bool, error) {
+	if err := r.Check(b); err != nil {
+		return false, err
+	}
+	if countSolutions(b, r.lay, 2) != 1 {
+		return false, nil
+	}
+	return isMinimal(context.Background(), b, r.lay), nil
+}
+
+// isMinimal checks minimality of a puzzle already known to be unique. It
+// reports false when ctx is done before every clue was tried.
+func isMinimal(ctx context.Context, b Board, l *layout) bool {
+	work := b.Clone()
+	for i, value := range work {
+		if value == 0 {
+			continue
+		}
+		work[i] = 0
+		unique := countSolutionsContext(ctx, work, l, 2) == 1
+		work[i] = value
+		if unique || ctx.Err() != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestIsMinimal checks minimality on a minimal puzzle, the same puzzle
+// with a spare clue, and boards that are not unique.
+func TestIsMinimal(t *testing.T) {
+	p, err := GenerateWith(context.Background(), Geometry6, Easy, Options{Seed: 1, Minimal: true})
+	if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+		t.Fatal(err)
+	}
+	spare := p.Givens.Clone()
+	for i, value := range spare {
+		if value == 0 {
+			spare[i] = p.Solution[i]
+			break
+		}
+	}
+	cases := []struct {
+		name string
+		b    Board
+		want bool
+	}{
+		{"minimal", p.Givens, true},
+		{"spare clue", spare, false},
+		{"empty", make(Board, Geometry6.Cells()), false},
+		{"unsolvable", unsolvable(t, p), false},
+	}
+	for _, c := range cases {
+		got, err := IsMinimal(Geometry6, c.b)
+		if err != nil || got != c.want {
+			t.Errorf("%s: IsMinimal = %v, %v, want %v", c.name, got, err, c.want)
+		}
+	}
+	if _, err := IsMinimal(Geometry6, p.Givens[:5]); !errors.Is(err, ErrInvalidBoard) {
+		t.Errorf("short board: error %v, want ErrInvalidBoard", err)
+	}
+}
+
+// TestGenerateMinimalAndClues checks that Minimal and Clues shape the
+// carved givens and that the puzzle reports them.
+func TestGenerateMinimalAndClues(t *testing.T) {
+	cases := []struct {
+		g    Geometry
+		opts Options
+	}{
+		{Geometry6, Options{Seed: 1, Minimal: true}},
+		{Geometry9, Options{Seed: 2, Minimal: true}},
+		{Geometry6, Options{Seed: 3, Clues: 14}},
+		{Geometry9, Options{Seed: 4, Clues: 30}},
+		{Geometry9, Options{Seed: 5, Clues: 26, Minimal: true}},
+	}
+	for _, c := range cases {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		p, err := GenerateWith(ctx, c.g, Easy, c.opts)
+		cancel()
+		if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+			t.Fatalf("%s %+v: %v", c.g, c.opts, err)
+		}
+		if p.Clues != p.Givens.Filled() {
+			t.Errorf("%s %+v: Clues %d, givens hold %d", c.g, c.opts, p.Clues, p.Givens.Filled())
+		}
+		if c.opts.Clues > 0 && p.Clues != c.opts.Clues {
+			t.Errorf("%s %+v: %d clues", c.g, c.opts, p.Clues)
+		}
+		minimal, err := IsMinimal(c.g, p.Givens)
+		if err != nil || minimal != p.Minimal {
+			t.Errorf("%s %+v: Minimal %v, IsMinimal %v, %v", c.g, c.opts, p.Minimal, minimal, err)
+		}
+		if c.opts.Minimal && !minimal {
+			t.Errorf("%s %+v: puzzle is not minimal", c.g, c.opts)
+		}
+	}
+}
+
+// TestCluesOutOfRange checks that impossible clue counts are rejected.
+func TestCluesOutOfRange(t *testing.T) {
+	for _, clues := range []int{-1, Geometry6.Cells() + 1} {
+		_, err := GenerateWith(context.Background(), Geometry6, Easy, Options{Clues: clues})
+		if !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("%d clues: error %v, want ErrInvalidOptions", clues, err)
+		}
+	}
+}
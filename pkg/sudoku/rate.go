@@ -0,0 +1,186 @@
+package sudoku
+
+// Rating describes how hard a puzzle is for a human solver.
+type Rating struct {
+	// Score is a Sudoku Explainer style number: the hardest technique's
+	// score plus a small bonus when it is needed repeatedly.
+	Score float64
+	// Difficulty is the bucket of the hardest technique.
+	Difficulty Difficulty
+	// Hardest is the hardest technique the solve needed.
+	Hardest Technique
+	// HardestCount is how many steps used the hardest technique; for
+	// TrialAndError it counts guesses.
+	HardestCount int
+	// Steps is the total number of logical steps.
+	Steps int
+}
+
+// The score bonus for repeated hardest steps grows by repeatBonus per
+// extra step up to maxRepeatBonus, which stays below the smallest gap
+// between two distinct base scores (0.1) so a repeated easier technique
+// never reaches the score of a harder one.
+const (
+	repeatBonus    = 0.02
+	maxRepeatBonus = 0.08
+)
+
+// Score returns the technique's base score on a Sudoku Explainer style
+// scale from 1.0 (trivial) to 10.0 (brutal).
+func (t Technique) Score() float64 {
+	switch t {
+	case HiddenSingle:
+		return 1.5
+	case NakedSingle:
+		return 2.3
+	case CageCombination, ThermoOrder, KropkiDot, ArrowSum:
+		return 2.4
+	case PointingCandidates:
+		return 2.6
+	case InnieOutie:
+		return 2.7
+	case BoxLineReduction:
+		return 2.8
+	case NakedPair:
+		return 3.0
+	case XWing:
+		return 3.2
+	case HiddenPair:
+		return 3.4
+	case NakedTriple:
+		return 3.6
+	case Swordfish:
+		return 3.8
+	case HiddenTriple:
+		return 4.0
+	case XYWing:
+		return 4.2
+	case XYZWing, WWing:
+		return 4.4
+	case UniqueRectangle1, UniqueRectangle2:
+		return 4.5
+	case UniqueRectangle3, UniqueRectangle4:
+		return 4.6
+	case NakedQuad:
+		return 5.0
+	case Jellyfish:
+		return 5.2
+	case HiddenQuad:
+		return 5.4
+	case BUGPlusOne:
+		return 5.6
+	case SimpleColoring:
+		return 5.8
+	case XChain:
+		return 6.5
+	case XYChain:
+		return 6.6
+	case AlternatingChain:
+		return 7.0
+	default:
+		return 9.0
+	}
+}
+
+// Difficulty returns the bucket a puzzle lands in when this is the
+// hardest technique it needs.
+func (t Technique) Difficulty() Difficulty {
+	switch t {
+	case HiddenSingle, NakedSingle, ThermoOrder, KropkiDot, ArrowSum, CageCombination:
+		return Easy
+	case PointingCandidates, InnieOutie, BoxLineReduction, NakedPair, HiddenPair, NakedTriple, HiddenTriple:
+		return Medium
+	case XWing, Swordfish, XYWing, XYZWing, WWing:
+		return Hard
+	case UniqueRectangle1, UniqueRectangle2, UniqueRectangle3, UniqueRectangle4,
+		NakedQuad, Jellyfish, HiddenQuad, BUGPlusOne, SimpleColoring:
+		return Expert
+	case XChain, XYChain, AlternatingChain:
+		return Evil
+	default:
+		return Diabolical
+	}
+}
+
+// Rate solves a puzzle with the technique engine and rates it by the
+// hardest technique needed and how often it was needed. When logic
+// stalls, it guesses the correct digit in the cell with the fewest
+// candidates and continues, rating the puzzle as TrialAndError.
+// The puzzle must have a unique solution.
+func Rate(g Geometry, b Board) (Rating, error) {
+	r, err := StandardRules(g)
+	if err != nil {
+		return Rating{}, err
+	}
+	return r.Rate(b)
+}
+
+// Rate scores a puzzle under the rules, as the package-level Rate does
+// for the standard ones.
+func (r *Rules) Rate(b Board) (Rating, error) {
+	solution, err := r.Solve(b)
+	if err != nil {
+		return Rating{}, err
+	}
+	gr, err := r.NewGrid(b)
+	if err != nil {
+		return Rating{}, err
+	}
+	return rateGrid(gr, solution), nil
+}
+
+// rateGrid runs the technique engine to completion, guessing from the
+// known solution whenever it stalls.
+func rateGrid(gr *Grid, solution Board) Rating {
+	rating := Rating{Hardest: HiddenSingle}
+	counts := map[Technique]int{}
+	guesses := 0
+	for !gr.Solved() {
+		step, ok := gr.NextStep()
+		if !ok {
+			guesses++
+			cell := fewestCandidates(gr)
+			gr.Place(cell, solution[cell])
+			continue
+		}
+		gr.Apply(step)
+		rating.Steps++
+		counts[step.Technique]++
+		// Techniques sharing a base score are counted apart; among them
+		// the one used most often is the hardest.
+		t := step.Technique
+		if t.Score() > rating.Hardest.Score() ||
+			t.Score() == rating.Hardest.Score() && counts[t] > counts[rating.Hardest] {
+			rating.Hardest = t
+		}
+	}
+	rating.HardestCount = counts[rating.Hardest]
+	if guesses > 0 {
+		rating.Hardest = TrialAndError
+		rating.HardestCount = guesses
+	}
+	bonus := repeatBonus * float64(rating.HardestCount-1)
+	if bonus > maxRepeatBonus {
+		bonus = maxRepeatBonus
+	}
+	if bonus < 0 {
+		bonus = 0
+	}
+	rating.Score = rating.Hardest.Score() + bonus
+	rating.Difficulty = rating.Hardest.Difficulty()
+	return rating
+}
+
+// fewestCandidates returns the empty cell with the fewest candidates.
+func fewestCandidates(gr *Grid) int {
+	best, bestCount := -1, gr.lay.geo.Size+1
+	for i, mask := range gr.cands {
+		if gr.values[i] != 0 {
+			continue
+		}
+		if count := bitCount(mask); count < bestCount {
+			best, bestCount = i, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,17 @@
+package sudoku
+
+import "testing"
+
+// TestRepeatBonusBelowScoreGaps checks that a repeated technique at its
+// full bonus still scores below every technique with a higher base score.
+func TestRepeatBonusBelowScoreGaps(t *testing.T) {
+	all := append([]Technique{TrialAndError}, Techniques...)
+	for _, a := range all {
+		for _, b := range all {
+			if b.Score() > a.Score() && a.Score()+maxRepeatBonus >= b.Score() {
+				t.Errorf("%v with the full bonus (%.2f) reaches %v (%.2f)",
+					a, a.Score()+maxRepeatBonus, b, b.Score())
+			}
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package sudoku
+
+import "context"
+
+// CandidateMask returns a bitmask of legal digits for an empty cell under
+// the standard rules. Filled cells return 0.
+func CandidateMask(g Geometry, b Board, row, col int) uint16 {
+	r, err := StandardRules(g)
+	if err != nil {
+		return 0
+	}
+	return r.CandidateMask(b, row, col)
+}
+
+// CandidateMask returns a bitmask of the digits an empty cell may take
+// under the rules. Filled cells return 0.
+func (r *Rules) CandidateMask(b Board, row, col int) uint16 {
+	l := r.lay
+	i := l.geo.Index(row, col)
+	if b[i] != 0 {
+		return 0
+	}
+	used := uint16(0)
+	for _, j := range l.peers[i] {
+		if value := b[j]; value != 0 {
+			used |= 1 << uint(value-1)
+		}
+	}
+	mask := l.geo.FullMask() &^ used
+	if mask != 0 && len(l.pruners) > 0 {
+		mask &= l.allowed(b, i)
+	}
+	return mask
+}
+
+// Counter selects the algorithm used to count solutions.
+type Counter int
+
+const (
+	// AutoCounter picks the fastest backend for the geometry.
+	AutoCounter Counter = iota
+	// BacktrackCounter searches cell by cell, rescanning peers at each node.
+	BacktrackCounter
+	// DLXCounter solves the exact-cover form with Dancing Links.
+	DLXCounter
+)
+
+// String returns the backend name.
+func (c Counter) String() string {
+	switch c {
+	case BacktrackCounter:
+		return "backtrack"
+	case DLXCounter:
+		return "dlx"
+	default:
+		return "auto"
+	}
+}
+
+// Solve returns the unique solution of a puzzle under the standard rules.
+// It fails with ErrNoSolution or ErrMultipleSolutions when the puzzle is
+// not proper.
+func Solve(g Geometry, b Board) (Board, error) {
+	r, err := StandardRules(g)
+	if err != nil {
+		return nil, err
+	}
+	return r.Solve(b)
+}
+
+// Solve returns the unique solution of a puzzle under the rules.
+func (r *Rules) Solve(b Board) (Board, error) {
+	if err := r.Check(b); err != nil {
+		return nil, err
+	}
+	var first Board
+	switch countSolutionsFirst(context.Background(), AutoCounter, b, r.lay, 2, &first) {
+	case 0:
+		return nil, ErrNoSolution
+	case 1:
+		return first, nil
+	default:
+		return nil, ErrMultipleSolutions
+	}
+}
+
+// CountSolutions counts solutions of a puzzle, stopping at limit.
+func CountSolutions(g Geometry, b Board, limit int) (int, error) {
+	return CountSolutionsWith(AutoCounter, g, b, limit)
+}
+
+// CountSolutionsWith counts solutions with a specific backend.
+func CountSolutionsWith(c Counter, g Geometry, b Board, limit int) (int, error) {
+	r, err := StandardRules(g)
+	if err != nil {
+		return 0, err
+	}
+	return r.CountSolutionsWith(c, b, limit)
+}
+
+// CountSolutions counts solutions under the rules, stopping at limit.
+func (r *Rules) CountSolutions(b Board, limit int) (int, error) {
+	return r.CountSolutionsWith(AutoCounter, b, limit)
+}
+
+// CountSolutionsWith counts solutions under the rules with a specific
+// backend. Dancing Links needs every region to be a house and no other
+// constraints; for other rules DLXCounter falls back to backtracking.
+func (r *Rules) CountSolutionsWith(c Counter, b Board, limit int) (int, error) {
+	if err := r.Check(b); err != nil {
+		return 0, err
+	}
+	return countSolutionsWith(c, b, r.lay, limit), nil
+}
+
+// countSolutions counts solutions up to a limit without validating input.
+func countSolutions(b Board, l *layout, limit int) int {
+	return countSolutionsWith(AutoCounter, b, l, limit)
+}
+
+// countSolutionsWith dispatches to a backend without validating input.
+func countSolutionsWith(c Counter, b Board, l *layout, limit int) int {
+	return countSolutionsFirst(context.Background(), c, b, l, limit, nil)
+}
+
+// countSolutionsContext counts solutions up to a limit and stops when ctx
+// is done, so the count is short of the truth once ctx.Err is set.
+func countSolutionsContext(ctx context.Context, b Board, l *layout, limit int) int {
+	return countSolutionsFirst(ctx, AutoCounter, b, l, limit, nil)
+}
+
+// countSolutionsFirst dispatches to a backend and, when first is not
+// nil, stores the first solution found in it. Both backends stop when
+// ctx is done.
+func countSolutionsFirst(ctx context.Context, c Counter, b Board, l *layout, limit int, first *Board) int {
+	s := &stopper{ctx: ctx}
+	if c == AutoCounter {
+		c = preferredCounter(l)
+	}
+	if c == DLXCounter && l.exact {
+		m, ok := newDLX(l, b)
+		if !ok {
+			return 0
+		}
+		if first != nil {
+			m.base = b
+		}
+		n := m.count(s, limit)
+		if first != nil {
+			*first = m.first
+		}
+		return n
+	}
+	return searchSolutions(s, newState(l, b), limit, first)
+}
+
+// dlxMinSize is the smallest board where Dancing Links beats plain
+// backtracking; below it, copying the matrix costs more than the search
+// saves.
+const dlxMinSize = 9
+
+// preferredCounter picks the backend that benchmarks faster for a layout.
+func preferredCounter(l *layout) Counter {
+	if l.exact && l.geo.Size >= dlxMinSize {
+		return DLXCounter
+	}
+	return BacktrackCounter
+}
+
+// searchSolutions explores solutions with backtracking, branching on the
+// empty cell with the fewest candidates, or on a digit's only place in a
+// house under rules with more than regions. When first is not nil and
+// still empty, the first solution reached is copied into it. It counts
+// nothing more once s stops.
+func searchSolutions(s *stopper, st *State, limit int, first *Board) int {
+	if s.stop() {
+		return 0
+	}
+	emptyIndex, candidates, ok := st.branch()
+	if !ok {
+		return 0
+	}
+	if emptyIndex == -1 {
+		if first != nil && *first == nil {
+			*first = st.values.Clone()
+		}
+		return 1
+	}
+	total := 0
+	for _, value := range maskToValues(candidates, st.lay.geo.Size) {
+		st.Set(emptyIndex, uint8(value))
+		total += searchSolutions(s, st, limit-total, first)
+		if total >= limit {
+			break
+		}
+	}
+	st.Set(emptyIndex, 0)
+	return total
+}
+
+// stopCheck is how many search nodes pass between looks at the context;
+// asking at every node slows Dancing Links down noticeably.
+const stopCheck = 1024
+
+// stopper tells a search when its context is done.
+type stopper struct {
+	ctx     context.Context
+	nodes   int
+	stopped bool
+}
+
+// stop counts a search node and reports whether the search should give
+// up, looking at the context every stopCheck nodes.
+func (s *stopper) stop() bool {
+	if !s.stopped {
+		s.nodes++
+		s.stopped = s.nodes%stopCheck == 0 && s.ctx.Err() != nil
+	}
+	return s.stopped
+}
+
+// branch picks the cell the search tries next and the digits to try
+// there, returning -1 when the board is full. It reports false at a dead
+// end. Sums and other pruners leave cells with many loose candidates, so
+// under them narrowers get to shrink the candidates first, and a digit
+// with a single place in a house is preferred.
+func (s *State) branch() (int, uint16, bool) {
+	size := s.lay.geo.Size
+	var cands []uint16
+	if len(s.lay.pruners) > 0 {
+		cands = make([]uint16, len(s.values))
+	}
+	best, bestMask, bestCount := -1, uint16(0), size+1
+	for i, value := range s.values {
+		if value != 0 {
+			continue
+		}
+		mask := s.Candidates(i)
+		count := bitCount(mask)
+		if count == 0 {
+			return -1, 0, false
+		}
+		if cands != nil {
+			cands[i] = mask
+		}
+		if count < bestCount {
+			best, bestMask, bestCount = i, mask, count
+			if count == 1 {
+				break
+			}
+		}
+	}
+	if cands == nil || bestCount <= 1 {
+		return best, bestMask, true
+	}
+	for _, n := range s.lay.narrowers {
+		if !n.narrow(s.lay.geo, s.values, cands) {
+			return -1, 0, false
+		}
+	}
+	bestCount = size + 1
+	for i, mask := range cands {
+		if s.values[i] != 0 {
+			continue
+		}
+		count := bitCount(mask)
+		if count == 0 {
+			return -1, 0, false
+		}
+		if count < bestCount {
+			best, bestMask, bestCount = i, mask, count
+		}
+	}
+	if bestCount == 1 {
+		return best, bestMask, true
+	}
+	for _, cells := range s.lay.unitCells {
+		var once, twice, placed uint16
+		for _, i := range cells {
+			if value := s.values[i]; value != 0 {
+				placed |= 1 << uint(value-1)
+				continue
+			}
+			twice |= once & cands[i]
+			once |= cands[i]
+		}
+		if once|placed != s.lay.geo.FullMask() {
+			return -1, 0, false
+		}
+		if single := once &^ twice; single != 0 {
+			bit := uint16(1) << uint(firstBit(single)-1)
+			for _, i := range cells {
+				if cands[i]&bit != 0 {
+					return i, bit, true
+				}
+			}
+		}
+	}
+	return best, bestMask, true
+}
+
+// bitCount returns the number of set bits in a mask.
+func bitCount(mask uint16) int {
+	count := 0
+	for mask > 0 {
+		mask &= mask - 1
+		count++
+	}
+	return count
+}
+
+// firstBit returns the 1-based index of the lowest set bit.
+func firstBit(mask uint16) int {
+	for i := 0; i < 16; i++ {
+		if mask&(1<<uint(i)) != 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// maskToValues converts a mask to a list of candidate values.
+func maskToValues(mask uint16, size int) []int {
+	values := make([]int, 0, size)
+	for i := 0; i < size; i++ {
+		if mask&(1<<uint(i)) != 0 {
+			values = append(values, i+1)
+		}
+	}
+	return values
+}
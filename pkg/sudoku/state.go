@@ -0,0 +1,206 @@
+package sudoku
+
+import "fmt"
+
+// State tracks placed digits together with a "used" bitmask and digit
+// counts per region. Placing or clearing a digit, reading a cell's
+// candidates, and checking a cell for conflicts only touch the cell's
+// own regions instead of rescanning its peers; constraints beyond
+// regions are asked through Constraint.Allowed.
+//
+// Unlike Check, State accepts boards with duplicate digits so it can back
+// a player's grid; conflicts are reported by Conflict and HasConflicts.
+type State struct {
+	lay    *layout
+	values Board
+	used   []uint16
+	counts []uint8
+	dupes  int
+}
+
+// NewState builds a state for the standard rules of a geometry. The board
+// may contain conflicts but must match the geometry's length and digit
+// range.
+func NewState(g Geometry, b Board) (*State, error) {
+	r, err := StandardRules(g)
+	if err != nil {
+		return nil, err
+	}
+	return r.NewState(b)
+}
+
+// NewState builds a state from a board under the rules. The board may
+// contain conflicts but must match the geometry's length and digit range.
+func (r *Rules) NewState(b Board) (*State, error) {
+	g := r.lay.geo
+	if len(b) != g.Cells() {
+		return nil, fmt.Errorf("%w: %d cells, want %d", ErrInvalidBoard, len(b), g.Cells())
+	}
+	for i, value := range b {
+		if int(value) > g.Size {
+			return nil, &CellError{Row: i / g.Size, Col: i % g.Size, Value: value, Reason: "digit out of range"}
+		}
+	}
+	return newState(r.lay, b), nil
+}
+
+// newState builds a state from a board already known to be in range.
+func newState(l *layout, b Board) *State {
+	s := &State{
+		lay:    l,
+		values: make(Board, len(b)),
+		used:   make([]uint16, len(l.regions)),
+		counts: make([]uint8, len(l.regions)*l.geo.Size),
+	}
+	for i, value := range b {
+		s.Set(i, value)
+	}
+	return s
+}
+
+// Geometry returns the state's geometry.
+func (s *State) Geometry() Geometry {
+	return s.lay.geo
+}
+
+// Value returns the digit at a cell, or 0.
+func (s *State) Value(i int) uint8 {
+	return s.values[i]
+}
+
+// Values returns a copy of the board.
+func (s *State) Values() Board {
+	return s.values.Clone()
+}
+
+// Peers returns the cells sharing a region with a cell. The slice is
+// shared and must not be modified.
+func (s *State) Peers(i int) []int {
+	return s.lay.peers[i]
+}
+
+// Clone returns an independent copy of the state.
+func (s *State) Clone() *State {
+	return &State{
+		lay:    s.lay,
+		values: s.values.Clone(),
+		used:   append([]uint16(nil), s.used...),
+		counts: append([]uint8(nil), s.counts...),
+		dupes:  s.dupes,
+	}
+}
+
+// Set places a digit at a cell, or clears it when digit is 0.
+func (s *State) Set(i int, digit uint8) {
+	if old := s.values[i]; old != 0 {
+		s.values[i] = 0
+		s.adjust(i, old, -1)
+	}
+	if digit != 0 {
+		s.values[i] = digit
+		s.adjust(i, digit, 1)
+	}
+}
+
+// adjust updates the counts and used masks of a cell's regions.
+func (s *State) adjust(i int, digit uint8, delta int) {
+	size := s.lay.geo.Size
+	bit := uint16(1) << uint(digit-1)
+	for _, u := range s.lay.cellRegions[i] {
+		k := u*size + int(digit) - 1
+		before := s.counts[k]
+		s.counts[k] = uint8(int(before) + delta)
+		switch {
+		case delta > 0 && before == 0:
+			s.used[u] |= bit
+		case delta < 0 && before == 1:
+			s.used[u] &^= bit
+		}
+		switch {
+		case delta > 0 && before == 1:
+			s.dupes++
+		case delta < 0 && before == 2:
+			s.dupes--
+		}
+	}
+}
+
+// Rules returns the rules the state follows.
+func (s *State) Rules() *Rules {
+	return &Rules{lay: s.lay}
+}
+
+// Candidates returns the digits an empty cell may take: those not used
+// by any of its regions and allowed by every other constraint. Filled
+// cells return 0.
+func (s *State) Candidates(i int) uint16 {
+	if s.values[i] != 0 {
+		return 0
+	}
+	used := uint16(0)
+	for _, u := range s.lay.cellRegions[i] {
+		used |= s.used[u]
+	}
+	mask := s.lay.geo.FullMask() &^ used
+	if mask != 0 && len(s.lay.pruners) > 0 {
+		mask &= s.lay.allowed(s.values, i)
+	}
+	return mask
+}
+
+// Conflict reports whether a filled cell repeats a digit in one of its
+// regions or breaks another constraint.
+func (s *State) Conflict(i int) bool {
+	return s.repeats(i) || s.breaks(i)
+}
+
+// repeats reports whether a filled cell's digit appears twice in one of
+// its regions.
+func (s *State) repeats(i int) bool {
+	digit := s.values[i]
+	if digit == 0 {
+		return false
+	}
+	size := s.lay.geo.Size
+	for _, u := range s.lay.cellRegions[i] {
+		if s.counts[u*size+int(digit)-1] > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// breaks reports whether a filled cell's digit is ruled out by a
+// constraint beyond its regions.
+func (s *State) breaks(i int) bool {
+	digit := s.values[i]
+	if digit == 0 || len(s.lay.pruners) == 0 {
+		return false
+	}
+	return s.lay.allowed(s.values, i)&(1<<uint(digit-1)) == 0
+}
+
+// conflictReason describes why a cell conflicts, for CellError.
+func (s *State) conflictReason(i int) string {
+	if s.repeats(i) {
+		return "duplicate digit"
+	}
+	return "breaks a constraint"
+}
+
+// HasConflicts reports whether any region repeats a digit or any filled
+// cell breaks another constraint.
+func (s *State) HasConflicts() bool {
+	if s.dupes > 0 {
+		return true
+	}
+	if len(s.lay.pruners) == 0 {
+		return false
+	}
+	for i := range s.values {
+		if s.breaks(i) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package sudoku
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestStateMatchesRescan places and clears random digits, duplicates
+// included, and checks the incremental candidates and conflicts against
+// a rescan of every peer after each change.
+func TestStateMatchesRescan(t *testing.T) {
+	for _, g := range []Geometry{Geometry6, Geometry9, Geometry12} {
+		rng := rand.New(rand.NewSource(1))
+		r, err := StandardRules(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := r.NewState(make(Board, g.Cells()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for step := 0; step < 400; step++ {
+			i := rng.Intn(g.Cells())
+			digit := uint8(rng.Intn(g.Size + 1))
+			s.Set(i, digit)
+			b := s.Values()
+			dupes := false
+			for j := range b {
+				if want := r.CandidateMask(b, j/g.Size, j%g.Size); s.Candidates(j) != want {
+					t.Fatalf("%s step %d: r%dc%d candidates %b, want %b", g, step, j/g.Size+1, j%g.Size+1, s.Candidates(j), want)
+				}
+				conflict := false
+				for _, p := range s.Peers(j) {
+					conflict = conflict || b[j] != 0 && b[p] == b[j]
+				}
+				if s.Conflict(j) != conflict {
+					t.Fatalf("%s step %d: r%dc%d conflict %v, want %v", g, step, j/g.Size+1, j%g.Size+1, s.Conflict(j), conflict)
+				}
+				dupes = dupes || conflict
+			}
+			if s.HasConflicts() != dupes {
+				t.Fatalf("%s step %d: HasConflicts %v, want %v", g, step, s.HasConflicts(), dupes)
+			}
+		}
+	}
+}
+
+// TestStateClone checks that a clone changes independently.
+func TestStateClone(t *testing.T) {
+	s, err := NewState(Geometry9, make(Board, Geometry9.Cells()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set(0, 5)
+	c := s.Clone()
+	c.Set(1, 5)
+	if s.Value(1) != 0 || s.HasConflicts() {
+		t.Error("setting a clone changed the original")
+	}
+	if !c.HasConflicts() || !c.Conflict(0) {
+		t.Error("clone misses its own duplicate")
+	}
+	if s.Candidates(1)&(1<<4) != 0 {
+		t.Error("original offers a digit its row already holds")
+	}
+}
@@ -0,0 +1,132 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Candidate is a digit at a cell, used for placements and eliminations.
+type Candidate struct {
+	Cell  int
+	Digit uint8
+}
+
+// Link is one edge of a chain. A strong link means at least one end is
+// true; a weak link means at most one end is true.
+type Link struct {
+	From   Candidate
+	To     Candidate
+	Strong bool
+}
+
+// Step is a single logical deduction produced by the technique engine.
+type Step struct {
+	// Technique is the pattern that justified the deduction.
+	Technique Technique
+	// Units are the units the pattern lives in.
+	Units []Unit
+	// Base and Cover are the base and cover units of fish and
+	// intersection patterns: the digit's cells in Base all lie in Cover,
+	// so it is removed from the rest of Cover.
+	Base  []Unit
+	Cover []Unit
+	// Cells are the cells forming the pattern.
+	Cells []int
+	// Digits are the candidates the pattern is built from.
+	Digits []uint8
+	// Chain holds the ordered links of chain and coloring patterns.
+	Chain []Link
+	// Placements are digits the step proves.
+	Placements []Candidate
+	// Eliminations are candidates the step removes.
+	Eliminations []Candidate
+}
+
+// Describe returns a readable explanation such as
+// "Naked pair 2/7 in row 3 (r3c1 r3c4): r3c6≠2, r3c8≠7".
+func (s Step) Describe(g Geometry) string {
+	var b strings.Builder
+	b.WriteString(s.Technique.String())
+	if len(s.Digits) > 0 {
+		b.WriteString(" " + digitList(s.Digits))
+	}
+	if len(s.Units) > 0 {
+		b.WriteString(" in " + unitList(s.Units))
+	}
+	if len(s.Base) > 0 {
+		b.WriteString(" in " + unitList(s.Base) + " / " + unitList(s.Cover))
+	}
+	if len(s.Chain) > 0 {
+		b.WriteString(" " + chainString(g, s.Chain))
+	} else if len(s.Cells) > 0 {
+		b.WriteString(" (" + cellList(g, s.Cells) + ")")
+	}
+	b.WriteString(": ")
+	b.WriteString(s.effects(g))
+	return b.String()
+}
+
+// effects formats the placements and eliminations of a step.
+func (s Step) effects(g Geometry) string {
+	parts := make([]string, 0, len(s.Placements)+len(s.Eliminations))
+	for _, c := range s.Placements {
+		parts = append(parts, fmt.Sprintf("%s=%d", g.CellName(c.Cell), c.Digit))
+	}
+	for _, c := range s.Eliminations {
+		parts = append(parts, fmt.Sprintf("%s≠%d", g.CellName(c.Cell), c.Digit))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// digitList formats digits as "2/7".
+func digitList(digits []uint8) string {
+	parts := make([]string, len(digits))
+	for i, d := range digits {
+		parts[i] = fmt.Sprintf("%d", d)
+	}
+	return strings.Join(parts, "/")
+}
+
+// unitList formats units as "row 2, row 7".
+func unitList(units []Unit) string {
+	names := make([]string, len(units))
+	for i, u := range units {
+		names[i] = u.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// cellList formats cells as "r3c1 r3c4".
+func cellList(g Geometry, cells []int) string {
+	parts := make([]string, len(cells))
+	for i, c := range cells {
+		parts[i] = g.CellName(c)
+	}
+	return strings.Join(parts, " ")
+}
+
+// chainString formats links in Eureka style, e.g. "5r1c2=5r1c7-5r3c8".
+// Links that do not continue the previous one start a new segment.
+func chainString(g Geometry, chain []Link) string {
+	var b strings.Builder
+	for k, link := range chain {
+		if k == 0 || chain[k-1].To != link.From {
+			if k > 0 {
+				b.WriteString("; ")
+			}
+			b.WriteString(candidateName(g, link.From))
+		}
+		if link.Strong {
+			b.WriteString("=")
+		} else {
+			b.WriteString("-")
+		}
+		b.WriteString(candidateName(g, link.To))
+	}
+	return b.String()
+}
+
+// candidateName formats a candidate as "5r1c2".
+func candidateName(g Geometry, c Candidate) string {
+	return fmt.Sprintf("%d%s", c.Digit, g.CellName(c.Cell))
+}
@@ -0,0 +1,113 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Symmetry is a pattern the givens of a generated puzzle follow. Cells
+// are carved in orbits under the symmetry so every removal keeps it.
+type Symmetry int
+
+const (
+	SymmetryNone Symmetry = iota
+	SymmetryRotate180
+	SymmetryRotate90
+	SymmetryMirrorHorizontal
+	SymmetryMirrorVertical
+	SymmetryDiagonal
+)
+
+// Symmetries lists every symmetry mode.
+var Symmetries = []Symmetry{
+	SymmetryNone,
+	SymmetryRotate180,
+	SymmetryRotate90,
+	SymmetryMirrorHorizontal,
+	SymmetryMirrorVertical,
+	SymmetryDiagonal,
+}
+
+// String returns the display label for a symmetry.
+func (s Symmetry) String() string {
+	switch s {
+	case SymmetryNone:
+		return "None"
+	case SymmetryRotate180:
+		return "Rotate 180"
+	case SymmetryRotate90:
+		return "Rotate 90"
+	case SymmetryMirrorHorizontal:
+		return "Mirror H"
+	case SymmetryMirrorVertical:
+		return "Mirror V"
+	case SymmetryDiagonal:
+		return "Diagonal"
+	default:
+		return fmt.Sprintf("Symmetry(%d)", int(s))
+	}
+}
+
+// ParseSymmetry converts a case-insensitive label into a symmetry.
+func ParseSymmetry(value string) (Symmetry, error) {
+	for _, s := range Symmetries {
+		if strings.EqualFold(value, s.String()) {
+			return s, nil
+		}
+	}
+	return SymmetryNone, fmt.Errorf("%w: %q", ErrUnknownSymmetry, value)
+}
+
+// image maps a cell to its partner under one application of the symmetry.
+// Horizontal mirroring flips rows about the middle row, vertical mirroring
+// flips columns, and diagonal mirroring reflects across the main diagonal.
+func (s Symmetry) image(g Geometry, i int) int {
+	n := g.Size - 1
+	row, col := i/g.Size, i%g.Size
+	switch s {
+	case SymmetryRotate180:
+		return g.Index(n-row, n-col)
+	case SymmetryRotate90:
+		return g.Index(col, n-row)
+	case SymmetryMirrorHorizontal:
+		return g.Index(n-row, col)
+	case SymmetryMirrorVertical:
+		return g.Index(row, n-col)
+	case SymmetryDiagonal:
+		return g.Index(col, row)
+	default:
+		return i
+	}
+}
+
+// Orbits partitions the cells into groups the symmetry maps onto each
+// other. Carving or keeping whole orbits preserves the symmetry.
+func (s Symmetry) Orbits(g Geometry) [][]int {
+	seen := make([]bool, g.Cells())
+	var orbits [][]int
+	for i := range seen {
+		if seen[i] {
+			continue
+		}
+		var orbit []int
+		for j := i; !seen[j]; j = s.image(g, j) {
+			seen[j] = true
+			orbit = append(orbit, j)
+		}
+		orbits = append(orbits, orbit)
+	}
+	return orbits
+}
+
+// Matches reports whether the filled cells of b follow the symmetry.
+func (s Symmetry) Matches(g Geometry, b Board) bool {
+	if len(b) != g.Cells() {
+		return false
+	}
+	for i, v := range b {
+		if (v == 0) != (b[s.image(g, i)] == 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestSymmetryOrbits checks that orbits partition the board, that each
+// is closed under the symmetry, and how many there are.
+func TestSymmetryOrbits(t *testing.T) {
+	cases := []struct {
+		s      Symmetry
+		g      Geometry
+		orbits int
+	}{
+		{SymmetryNone, Geometry9, 81},
+		{SymmetryRotate180, Geometry9, 41},
+		{SymmetryRotate180, Geometry6, 18},
+		{SymmetryRotate90, Geometry9, 21},
+		{SymmetryRotate90, Geometry4, 4},
+		{SymmetryMirrorHorizontal, Geometry9, 45},
+		{SymmetryMirrorVertical, Geometry8, 32},
+		{SymmetryDiagonal, Geometry9, 45},
+	}
+	for _, c := range cases {
+		orbits := c.s.Orbits(c.g)
+		if len(orbits) != c.orbits {
+			t.Errorf("%v on %s: %d orbits, want %d", c.s, c.g, len(orbits), c.orbits)
+		}
+		orbitOf := make([]int, c.g.Cells())
+		for i := range orbitOf {
+			orbitOf[i] = -1
+		}
+		for n, orbit := range orbits {
+			for _, i := range orbit {
+				if orbitOf[i] >= 0 {
+					t.Fatalf("%v on %s: cell %d in two orbits", c.s, c.g, i)
+				}
+				orbitOf[i] = n
+			}
+		}
+		for i, n := range orbitOf {
+			if n < 0 {
+				t.Fatalf("%v on %s: cell %d in no orbit", c.s, c.g, i)
+			}
+			if orbitOf[c.s.image(c.g, i)] != n {
+				t.Errorf("%v on %s: cell %d leaves its orbit", c.s, c.g, i)
+			}
+		}
+	}
+}
+
+// TestSymmetryMatches checks boards that keep and break a symmetry.
+func TestSymmetryMatches(t *testing.T) {
+	b := make(Board, Geometry4.Cells())
+	b[Geometry4.Index(0, 1)] = 1
+	b[Geometry4.Index(3, 2)] = 2
+	if !SymmetryRotate180.Matches(Geometry4, b) {
+		t.Error("rotated pair does not match Rotate 180")
+	}
+	if SymmetryMirrorHorizontal.Matches(Geometry4, b) {
+		t.Error("rotated pair matches Mirror H")
+	}
+	if SymmetryNone.Matches(Geometry4, b[:3]) {
+		t.Error("short board matches")
+	}
+}
+
+// TestGenerateKeepsSymmetry checks that carved givens follow each
+// symmetry.
+func TestGenerateKeepsSymmetry(t *testing.T) {
+	for _, s := range Symmetries {
+		p, err := GenerateWith(context.Background(), Geometry6, Easy, Options{Seed: 1, Symmetry: s})
+		if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+			t.Fatalf("%v: %v", s, err)
+		}
+		if !s.Matches(Geometry6, p.Givens) || p.Symmetry != s {
+			t.Errorf("%v: givens do not follow the symmetry", s)
+		}
+	}
+}
+
+// TestParseSymmetry checks that every label parses back.
+func TestParseSymmetry(t *testing.T) {
+	for _, s := range Symmetries {
+		if got, err := ParseSymmetry(s.String()); err != nil || got != s {
+			t.Errorf("ParseSymmetry(%q) = %v, %v", s.String(), got, err)
+		}
+	}
+	if _, err := ParseSymmetry("spiral"); !errors.Is(err, ErrUnknownSymmetry) {
+		t.Errorf("ParseSymmetry(spiral) error %v, want ErrUnknownSymmetry", err)
+	}
+}
@@ -0,0 +1,574 @@
+package sudoku
+
+// Technique names a human solving technique.
+type Technique int
+
+const (
+	HiddenSingle Technique = iota
+	NakedSingle
+	PointingCandidates
+	BoxLineReduction
+	NakedPair
+	XWing
+	HiddenPair
+	NakedTriple
+	Swordfish
+	HiddenTriple
+	XYWing
+	XYZWing
+	WWing
+	UniqueRectangle1
+	UniqueRectangle2
+	UniqueRectangle3
+	UniqueRectangle4
+	NakedQuad
+	Jellyfish
+	HiddenQuad
+	BUGPlusOne
+	SimpleColoring
+	XChain
+	XYChain
+	AlternatingChain
+	CageCombination
+	InnieOutie
+	ThermoOrder
+	KropkiDot
+	ArrowSum
+	// TrialAndError marks puzzles that logic alone cannot finish. It has
+	// no finder and is only reported by Rate.
+	TrialAndError
+)
+
+// Techniques lists every technique in the order the engine tries them,
+// from simplest to hardest.
+var Techniques = []Technique{
+	HiddenSingle,
+	NakedSingle,
+	ThermoOrder,
+	KropkiDot,
+	ArrowSum,
+	CageCombination,
+	PointingCandidates,
+	InnieOutie,
+	BoxLineReduction,
+	NakedPair,
+	XWing,
+	HiddenPair,
+	NakedTriple,
+	Swordfish,
+	HiddenTriple,
+	XYWing,
+	XYZWing,
+	WWing,
+	UniqueRectangle1,
+	UniqueRectangle2,
+	UniqueRectangle3,
+	UniqueRectangle4,
+	NakedQuad,
+	Jellyfish,
+	HiddenQuad,
+	BUGPlusOne,
+	SimpleColoring,
+	XChain,
+	XYChain,
+	AlternatingChain,
+}
+
+// String returns the display name of a technique.
+func (t Technique) String() string {
+	switch t {
+	case NakedSingle:
+		return "Naked single"
+	case HiddenSingle:
+		return "Hidden single"
+	case PointingCandidates:
+		return "Pointing candidates"
+	case BoxLineReduction:
+		return "Box/line reduction"
+	case NakedPair:
+		return "Naked pair"
+	case XWing:
+		return "X-Wing"
+	case HiddenPair:
+		return "Hidden pair"
+	case NakedTriple:
+		return "Naked triple"
+	case Swordfish:
+		return "Swordfish"
+	case HiddenTriple:
+		return "Hidden triple"
+	case XYWing:
+		return "XY-Wing"
+	case XYZWing:
+		return "XYZ-Wing"
+	case WWing:
+		return "W-Wing"
+	case UniqueRectangle1:
+		return "Unique rectangle type 1"
+	case UniqueRectangle2:
+		return "Unique rectangle type 2"
+	case UniqueRectangle3:
+		return "Unique rectangle type 3"
+	case UniqueRectangle4:
+		return "Unique rectangle type 4"
+	case NakedQuad:
+		return "Naked quad"
+	case Jellyfish:
+		return "Jellyfish"
+	case HiddenQuad:
+		return "Hidden quad"
+	case BUGPlusOne:
+		return "BUG+1"
+	case SimpleColoring:
+		return "Simple coloring"
+	case XChain:
+		return "X-Chain"
+	case XYChain:
+		return "XY-Chain"
+	case AlternatingChain:
+		return "Alternating inference chain"
+	case CageCombination:
+		return "Cage combination"
+	case InnieOutie:
+		return "Innie/outie"
+	case ThermoOrder:
+		return "Thermometer order"
+	case KropkiDot:
+		return "Kropki dot"
+	case ArrowSum:
+		return "Arrow sum"
+	case TrialAndError:
+		return "Trial and error"
+	default:
+		return "Unknown technique"
+	}
+}
+
+// finder searches a grid for one instance of a technique.
+type finder func(gr *Grid) (Step, bool)
+
+// finders maps each technique to its search function.
+var finders = map[Technique]finder{
+	HiddenSingle:       findHiddenSingle,
+	NakedSingle:        findNakedSingle,
+	PointingCandidates: findPointing,
+	BoxLineReduction:   findBoxLine,
+	NakedPair:          func(gr *Grid) (Step, bool) { return findNakedSubset(gr, 2) },
+	XWing:              func(gr *Grid) (Step, bool) { return findFish(gr, 2) },
+	HiddenPair:         func(gr *Grid) (Step, bool) { return findHiddenSubset(gr, 2) },
+	NakedTriple:        func(gr *Grid) (Step, bool) { return findNakedSubset(gr, 3) },
+	Swordfish:          func(gr *Grid) (Step, bool) { return findFish(gr, 3) },
+	HiddenTriple:       func(gr *Grid) (Step, bool) { return findHiddenSubset(gr, 3) },
+	XYWing:             findXYWing,
+	XYZWing:            findXYZWing,
+	WWing:              findWWing,
+	UniqueRectangle1:   func(gr *Grid) (Step, bool) { return findUniqueRectangle(gr, 1) },
+	UniqueRectangle2:   func(gr *Grid) (Step, bool) { return findUniqueRectangle(gr, 2) },
+	UniqueRectangle3:   func(gr *Grid) (Step, bool) { return findUniqueRectangle(gr, 3) },
+	UniqueRectangle4:   func(gr *Grid) (Step, bool) { return findUniqueRectangle(gr, 4) },
+	NakedQuad:          func(gr *Grid) (Step, bool) { return findNakedSubset(gr, 4) },
+	Jellyfish:          func(gr *Grid) (Step, bool) { return findFish(gr, 4) },
+	HiddenQuad:         func(gr *Grid) (Step, bool) { return findHiddenSubset(gr, 4) },
+	BUGPlusOne:         findBUG,
+	SimpleColoring:     findSimpleColoring,
+	XChain:             findXChain,
+	XYChain:            findXYChain,
+	AlternatingChain:   findAIC,
+	CageCombination:    findCageCombination,
+	InnieOutie:         findInnieOutie,
+	ThermoOrder:        findThermoOrder,
+	KropkiDot:          findKropkiDot,
+	ArrowSum:           findArrowSum,
+}
+
+// assumesClassic reports whether a technique's reasoning only holds under
+// the standard rules. Uniqueness arguments swap digits between cells,
+// which extra regions or constraints may forbid.
+func (t Technique) assumesClassic() bool {
+	switch t {
+	case UniqueRectangle1, UniqueRectangle2, UniqueRectangle3, UniqueRectangle4, BUGPlusOne:
+		return true
+	default:
+		return false
+	}
+}
+
+// NextStep returns the simplest deduction available in the grid.
+// Uniqueness techniques only run under the standard rules.
+func (gr *Grid) NextStep() (Step, bool) {
+	if gr.Solved() || gr.Broken() {
+		return Step{}, false
+	}
+	for _, t := range Techniques {
+		if t.assumesClassic() && !gr.lay.classic {
+			continue
+		}
+		if step, ok := finders[t](gr); ok {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
+
+// SolveLogically applies techniques until the puzzle is solved or the
+// engine is stuck. It returns the resulting board and the step trace;
+// check Board.Complete to see whether logic alone finished the puzzle.
+func SolveLogically(g Geometry, b Board) (Board, []Step, error) {
+	r, err := StandardRules(g)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.SolveLogically(b)
+}
+
+// SolveLogically solves under the rules like the package-level
+// SolveLogically.
+func (r *Rules) SolveLogically(b Board) (Board, []Step, error) {
+	gr, err := r.NewGrid(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	var steps []Step
+	for {
+		step, ok := gr.NextStep()
+		if !ok {
+			break
+		}
+		gr.Apply(step)
+		steps = append(steps, step)
+	}
+	if gr.Broken() {
+		return gr.Values(), steps, ErrNoSolution
+	}
+	return gr.Values(), steps, nil
+}
+
+// findNakedSingle finds a cell with exactly one candidate.
+func findNakedSingle(gr *Grid) (Step, bool) {
+	for i, mask := range gr.cands {
+		if mask != 0 && bitCount(mask) == 1 {
+			digit := uint8(firstBit(mask))
+			return Step{
+				Technique:  NakedSingle,
+				Cells:      []int{i},
+				Placements: []Candidate{{Cell: i, Digit: digit}},
+			}, true
+		}
+	}
+	return Step{}, false
+}
+
+// findHiddenSingle finds a digit with a single possible cell in a unit.
+func findHiddenSingle(gr *Grid) (Step, bool) {
+	for u := range gr.lay.units {
+		for d := 1; d <= gr.lay.geo.Size; d++ {
+			digit := uint8(d)
+			cells := gr.digitCells(u, digit)
+			if len(cells) != 1 || gr.unitHas(u, digit) {
+				continue
+			}
+			return Step{
+				Technique:  HiddenSingle,
+				Units:      []Unit{gr.lay.units[u]},
+				Cells:      cells,
+				Digits:     []uint8{digit},
+				Placements: []Candidate{{Cell: cells[0], Digit: digit}},
+			}, true
+		}
+	}
+	return Step{}, false
+}
+
+// findPointing finds a digit confined to one row or column inside a box,
+// which removes it from the rest of that line.
+func findPointing(gr *Grid) (Step, bool) {
+	for u, unit := range gr.lay.units {
+		if unit.Kind != BoxUnit {
+			continue
+		}
+		for d := 1; d <= gr.lay.geo.Size; d++ {
+			cells := gr.digitCells(u, uint8(d))
+			if len(cells) < 2 {
+				continue
+			}
+			for _, line := range sharedLines(gr.lay, cells) {
+				if step, ok := confinedStep(gr, PointingCandidates, u, line, uint8(d), cells); ok {
+					return step, true
+				}
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// findBoxLine finds a digit confined to one box inside a row or column,
+// which removes it from the rest of that box.
+func findBoxLine(gr *Grid) (Step, bool) {
+	for u, unit := range gr.lay.units {
+		if unit.Kind == BoxUnit {
+			continue
+		}
+		for d := 1; d <= gr.lay.geo.Size; d++ {
+			cells := gr.digitCells(u, uint8(d))
+			if len(cells) < 2 {
+				continue
+			}
+			box, ok := sharedBox(gr.lay, cells)
+			if !ok {
+				continue
+			}
+			if step, ok := confinedStep(gr, BoxLineReduction, u, box, uint8(d), cells); ok {
+				return step, true
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// confinedStep builds an intersection step: the digit's cells in base all
+// lie in cover, so it is removed from the rest of cover.
+func confinedStep(gr *Grid, t Technique, base, cover int, digit uint8, cells []int) (Step, bool) {
+	elims := eliminateOutside(gr, gr.lay.unitCells[cover], cells, digit)
+	if len(elims) == 0 {
+		return Step{}, false
+	}
+	return Step{
+		Technique:    t,
+		Base:         []Unit{gr.lay.units[base]},
+		Cover:        []Unit{gr.lay.units[cover]},
+		Cells:        cells,
+		Digits:       []uint8{digit},
+		Eliminations: elims,
+	}, true
+}
+
+// sharedLines returns the row and column units containing every cell.
+func sharedLines(l *layout, cells []int) []int {
+	var lines []int
+	for _, u := range l.cellUnits[cells[0]] {
+		if l.units[u].Kind == BoxUnit {
+			continue
+		}
+		if unitContainsAll(l, u, cells) {
+			lines = append(lines, u)
+		}
+	}
+	return lines
+}
+
+// sharedBox returns the box unit containing every cell, if any.
+func sharedBox(l *layout, cells []int) (int, bool) {
+	for _, u := range l.cellUnits[cells[0]] {
+		if l.units[u].Kind == BoxUnit && unitContainsAll(l, u, cells) {
+			return u, true
+		}
+	}
+	return 0, false
+}
+
+// unitContainsAll reports whether every cell belongs to unit u.
+func unitContainsAll(l *layout, u int, cells []int) bool {
+	for _, c := range cells {
+		found := false
+		for _, v := range l.cellUnits[c] {
+			if v == u {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// eliminateOutside lists digit eliminations for cells not in keep.
+func eliminateOutside(gr *Grid, cells, keep []int, digit uint8) []Candidate {
+	mask := uint16(1) << uint(digit-1)
+	var elims []Candidate
+	for _, i := range cells {
+		if gr.cands[i]&mask != 0 && !containsInt(keep, i) {
+			elims = append(elims, Candidate{Cell: i, Digit: digit})
+		}
+	}
+	return elims
+}
+
+// findNakedSubset finds n cells in a unit whose candidates span exactly
+// n digits, which removes those digits from the rest of the unit.
+func findNakedSubset(gr *Grid, n int) (Step, bool) {
+	t := [...]Technique{2: NakedPair, 3: NakedTriple, 4: NakedQuad}[n]
+	for u, cellsInUnit := range gr.lay.unitCells {
+		var pool []int
+		empty := 0
+		for _, i := range cellsInUnit {
+			if gr.values[i] != 0 {
+				continue
+			}
+			empty++
+			if count := bitCount(gr.cands[i]); count >= 2 && count <= n {
+				pool = append(pool, i)
+			}
+		}
+		if empty <= n {
+			continue
+		}
+		var found Step
+		ok := combinations(len(pool), n, func(pick []int) bool {
+			union := uint16(0)
+			cells := make([]int, n)
+			for k, p := range pick {
+				cells[k] = pool[p]
+				union |= gr.cands[pool[p]]
+			}
+			if bitCount(union) != n {
+				return false
+			}
+			var elims []Candidate
+			for _, i := range cellsInUnit {
+				if containsInt(cells, i) {
+					continue
+				}
+				for _, d := range maskToValues(gr.cands[i]&union, gr.lay.geo.Size) {
+					elims = append(elims, Candidate{Cell: i, Digit: uint8(d)})
+				}
+			}
+			if len(elims) == 0 {
+				return false
+			}
+			found = Step{
+				Technique:    t,
+				Units:        []Unit{gr.lay.units[u]},
+				Cells:        cells,
+				Digits:       maskDigits(union, gr.lay.geo.Size),
+				Eliminations: elims,
+			}
+			return true
+		})
+		if ok {
+			return found, true
+		}
+	}
+	return Step{}, false
+}
+
+// findHiddenSubset finds n digits confined to the same n cells of a unit,
+// which removes every other candidate from those cells.
+func findHiddenSubset(gr *Grid, n int) (Step, bool) {
+	t := [...]Technique{2: HiddenPair, 3: HiddenTriple, 4: HiddenQuad}[n]
+	size := gr.lay.geo.Size
+	for u, cellsInUnit := range gr.lay.unitCells {
+		var digits []uint8
+		positions := map[uint8]uint16{}
+		missing := 0
+		for d := 1; d <= size; d++ {
+			digit := uint8(d)
+			if gr.unitHas(u, digit) {
+				continue
+			}
+			missing++
+			pos := uint16(0)
+			for k, i := range cellsInUnit {
+				if gr.cands[i]&(1<<uint(d-1)) != 0 {
+					pos |= 1 << uint(k)
+				}
+			}
+			if count := bitCount(pos); count >= 2 && count <= n {
+				digits = append(digits, digit)
+				positions[digit] = pos
+			}
+		}
+		if missing <= n {
+			continue
+		}
+		var found Step
+		ok := combinations(len(digits), n, func(pick []int) bool {
+			union := uint16(0)
+			digitMask := uint16(0)
+			picked := make([]uint8, n)
+			for k, p := range pick {
+				picked[k] = digits[p]
+				union |= positions[digits[p]]
+				digitMask |= 1 << uint(digits[p]-1)
+			}
+			if bitCount(union) != n {
+				return false
+			}
+			var cells []int
+			var elims []Candidate
+			for k, i := range cellsInUnit {
+				if union&(1<<uint(k)) == 0 {
+					continue
+				}
+				cells = append(cells, i)
+				for _, d := range maskToValues(gr.cands[i]&^digitMask, size) {
+					elims = append(elims, Candidate{Cell: i, Digit: uint8(d)})
+				}
+			}
+			if len(elims) == 0 {
+				return false
+			}
+			found = Step{
+				Technique:    t,
+				Units:        []Unit{gr.lay.units[u]},
+				Cells:        cells,
+				Digits:       picked,
+				Eliminations: elims,
+			}
+			return true
+		})
+		if ok {
+			return found, true
+		}
+	}
+	return Step{}, false
+}
+
+// combinations calls fn with every k-subset of 0..n-1 in lexicographic
+// order, stopping early when fn returns true.
+func combinations(n, k int, fn func(pick []int) bool) bool {
+	if k > n || k <= 0 {
+		return false
+	}
+	pick := make([]int, k)
+	for i := range pick {
+		pick[i] = i
+	}
+	for {
+		if fn(pick) {
+			return true
+		}
+		i := k - 1
+		for i >= 0 && pick[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return false
+		}
+		pick[i]++
+		for j := i + 1; j < k; j++ {
+			pick[j] = pick[j-1] + 1
+		}
+	}
+}
+
+// maskDigits converts a candidate mask to digits.
+func maskDigits(mask uint16, size int) []uint8 {
+	values := maskToValues(mask, size)
+	digits := make([]uint8, len(values))
+	for i, v := range values {
+		digits[i] = uint8(v)
+	}
+	return digits
+}
+
+// containsInt reports whether a slice holds a value.
+func containsInt(values []int, target int) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,424 @@
+package sudoku
+
+import (
+	"slices"
+	"testing"
+)
+
+// techniqueCase sets up candidates on an open grid and names the step a
+// technique must find there.
+type techniqueCase struct {
+	name  string
+	t     Technique
+	g     Geometry
+	setup func(gr *Grid)
+	place []Candidate
+	elim  []Candidate
+}
+
+// at returns the index of a 1-based row and column on a 9x9 board.
+func at(r, c int) int {
+	return Geometry9.Index(r-1, c-1)
+}
+
+// openGrid returns an empty grid of a geometry with every candidate open.
+func openGrid(tb testing.TB, g Geometry) *Grid {
+	tb.Helper()
+	gr, err := NewGrid(g, make(Board, g.Cells()))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return gr
+}
+
+// keep narrows cells to the digits listed, such as "12".
+func keep(gr *Grid, digits string, cells ...int) {
+	for _, i := range cells {
+		gr.cands[i] = digitMask(digits)
+	}
+}
+
+// digitMask turns a string of digits 1-9 into a candidate mask.
+func digitMask(digits string) uint16 {
+	var mask uint16
+	for _, d := range digits {
+		mask |= 1 << uint(d-'1')
+	}
+	return mask
+}
+
+// remove deletes digits from every cell but the kept ones of a unit.
+func remove(gr *Grid, digits string, unit Unit, kept ...int) {
+	for _, i := range gr.lay.geo.UnitCells(unit) {
+		if containsInt(kept, i) {
+			continue
+		}
+		for _, d := range digits {
+			gr.Eliminate(i, uint8(d-'0'))
+		}
+	}
+}
+
+// cands lists each digit of digits at each cell.
+func cands(digits string, cells ...int) []Candidate {
+	var list []Candidate
+	for _, i := range cells {
+		for _, d := range digits {
+			list = append(list, Candidate{Cell: i, Digit: uint8(d - '0')})
+		}
+	}
+	return list
+}
+
+// row, col and box name units by 1-based index.
+func row(n int) Unit { return Unit{Kind: RowUnit, Index: n - 1} }
+func col(n int) Unit { return Unit{Kind: ColumnUnit, Index: n - 1} }
+func box(n int) Unit { return Unit{Kind: BoxUnit, Index: n - 1} }
+
+// runTechniqueCases checks that each case's finder reports exactly the
+// expected placements and eliminations.
+func runTechniqueCases(t *testing.T, cases []techniqueCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := c.g
+			if g.Size == 0 {
+				g = Geometry9
+			}
+			gr := openGrid(t, g)
+			c.setup(gr)
+			step, ok := finders[c.t](gr)
+			if !ok {
+				t.Fatalf("%v found nothing", c.t)
+			}
+			if step.Technique != c.t {
+				t.Errorf("step is %v, want %v", step.Technique, c.t)
+			}
+			if !sameCandidates(step.Placements, c.place) {
+				t.Errorf("placements %v, want %v\n%s", step.Placements, c.place, step.Describe(g))
+			}
+			if !sameCandidates(step.Eliminations, c.elim) {
+				t.Errorf("eliminations %v, want %v\n%s", step.Eliminations, c.elim, step.Describe(g))
+			}
+		})
+	}
+}
+
+// sameCandidates compares candidate lists ignoring order.
+func sameCandidates(a, b []Candidate) bool {
+	order := func(x, y Candidate) int {
+		if x.Cell != y.Cell {
+			return x.Cell - y.Cell
+		}
+		return int(x.Digit) - int(y.Digit)
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.SortFunc(a, order)
+	slices.SortFunc(b, order)
+	return slices.Equal(a, b)
+}
+
+// TestSinglesSubsetsIntersections runs each basic technique on a grid
+// that holds only its pattern.
+func TestSinglesSubsetsIntersections(t *testing.T) {
+	runTechniqueCases(t, []techniqueCase{
+		{
+			name:  "naked single",
+			t:     NakedSingle,
+			setup: func(gr *Grid) { keep(gr, "7", at(5, 5)) },
+			place: cands("7", at(5, 5)),
+		},
+		{
+			name:  "hidden single",
+			t:     HiddenSingle,
+			setup: func(gr *Grid) { remove(gr, "4", row(3), at(3, 6)) },
+			place: cands("4", at(3, 6)),
+		},
+		{
+			name: "pointing",
+			t:    PointingCandidates,
+			setup: func(gr *Grid) {
+				remove(gr, "3", box(1), at(1, 1), at(1, 2))
+			},
+			elim: cands("3", at(1, 4), at(1, 5), at(1, 6), at(1, 7), at(1, 8), at(1, 9)),
+		},
+		{
+			name: "box/line reduction",
+			t:    BoxLineReduction,
+			setup: func(gr *Grid) {
+				remove(gr, "6", row(2), at(2, 1), at(2, 2))
+			},
+			elim: cands("6", at(1, 1), at(1, 2), at(1, 3), at(3, 1), at(3, 2), at(3, 3)),
+		},
+		{
+			name:  "naked pair",
+			t:     NakedPair,
+			setup: func(gr *Grid) { keep(gr, "12", at(1, 1), at(1, 2)) },
+			elim:  cands("12", at(1, 3), at(1, 4), at(1, 5), at(1, 6), at(1, 7), at(1, 8), at(1, 9)),
+		},
+		{
+			name: "naked triple",
+			t:    NakedTriple,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(4, 1))
+				keep(gr, "23", at(4, 5))
+				keep(gr, "13", at(4, 9))
+			},
+			elim: cands("123", at(4, 2), at(4, 3), at(4, 4), at(4, 6), at(4, 7), at(4, 8)),
+		},
+		{
+			name: "naked quad",
+			t:    NakedQuad,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(7, 1))
+				keep(gr, "23", at(7, 2))
+				keep(gr, "34", at(7, 3))
+				keep(gr, "14", at(7, 4))
+			},
+			elim: cands("1234", at(7, 5), at(7, 6), at(7, 7), at(7, 8), at(7, 9)),
+		},
+		{
+			name:  "hidden pair",
+			t:     HiddenPair,
+			setup: func(gr *Grid) { remove(gr, "12", row(1), at(1, 1), at(1, 2)) },
+			elim:  cands("3456789", at(1, 1), at(1, 2)),
+		},
+		{
+			name:  "hidden triple",
+			t:     HiddenTriple,
+			setup: func(gr *Grid) { remove(gr, "456", row(2), at(2, 1), at(2, 4), at(2, 7)) },
+			elim:  cands("123789", at(2, 1), at(2, 4), at(2, 7)),
+		},
+		{
+			name:  "hidden quad",
+			t:     HiddenQuad,
+			setup: func(gr *Grid) { remove(gr, "1234", row(9), at(9, 1), at(9, 3), at(9, 5), at(9, 7)) },
+			elim:  cands("56789", at(9, 1), at(9, 3), at(9, 5), at(9, 7)),
+		},
+	})
+}
+
+// TestSolveLogicallyTrace checks every step of a logical solve against
+// the solution: placements match it and eliminations never remove it.
+func TestSolveLogicallyTrace(t *testing.T) {
+	for _, g := range []Geometry{Geometry6, Geometry9} {
+		p := fixedPuzzle(t, g, Medium)
+		_, steps, err := SolveLogically(g, p.Givens)
+		if err != nil {
+			t.Fatalf("%s: %v", g, err)
+		}
+		if len(steps) == 0 {
+			t.Fatalf("%s: no steps", g)
+		}
+		for n, step := range steps {
+			for _, c := range step.Placements {
+				if p.Solution[c.Cell] != c.Digit {
+					t.Errorf("%s step %d places a wrong digit: %s", g, n+1, step.Describe(g))
+				}
+			}
+			for _, c := range step.Eliminations {
+				if p.Solution[c.Cell] == c.Digit {
+					t.Errorf("%s step %d removes the solution: %s", g, n+1, step.Describe(g))
+				}
+			}
+		}
+	}
+}
+
+// TestFishAndWings runs each fish and wing on a grid that holds only its
+// pattern, including a fish on a 6x6 board.
+func TestFishAndWings(t *testing.T) {
+	at6 := func(r, c int) int { return Geometry6.Index(r-1, c-1) }
+	runTechniqueCases(t, []techniqueCase{
+		{
+			name: "x-wing",
+			t:    XWing,
+			setup: func(gr *Grid) {
+				remove(gr, "5", row(1), at(1, 2), at(1, 7))
+				remove(gr, "5", row(5), at(5, 2), at(5, 7))
+			},
+			elim: cands("5", at(2, 2), at(3, 2), at(4, 2), at(6, 2), at(7, 2), at(8, 2), at(9, 2),
+				at(2, 7), at(3, 7), at(4, 7), at(6, 7), at(7, 7), at(8, 7), at(9, 7)),
+		},
+		{
+			name: "x-wing 6x6",
+			t:    XWing,
+			g:    Geometry6,
+			setup: func(gr *Grid) {
+				remove(gr, "1", row(1), at6(1, 1), at6(1, 5))
+				remove(gr, "1", row(4), at6(4, 1), at6(4, 5))
+			},
+			elim: cands("1", at6(2, 1), at6(3, 1), at6(5, 1), at6(6, 1), at6(2, 5), at6(3, 5), at6(5, 5), at6(6, 5)),
+		},
+		{
+			name: "swordfish",
+			t:    Swordfish,
+			setup: func(gr *Grid) {
+				remove(gr, "8", row(2), at(2, 1), at(2, 4))
+				remove(gr, "8", row(5), at(5, 4), at(5, 7))
+				remove(gr, "8", row(8), at(8, 1), at(8, 7))
+			},
+			elim: cands("8",
+				at(1, 1), at(3, 1), at(4, 1), at(6, 1), at(7, 1), at(9, 1),
+				at(1, 4), at(3, 4), at(4, 4), at(6, 4), at(7, 4), at(9, 4),
+				at(1, 7), at(3, 7), at(4, 7), at(6, 7), at(7, 7), at(9, 7)),
+		},
+		{
+			name: "jellyfish",
+			t:    Jellyfish,
+			setup: func(gr *Grid) {
+				remove(gr, "9", row(1), at(1, 2), at(1, 4))
+				remove(gr, "9", row(3), at(3, 4), at(3, 6))
+				remove(gr, "9", row(6), at(6, 6), at(6, 8))
+				remove(gr, "9", row(9), at(9, 8), at(9, 2))
+			},
+			elim: cands("9",
+				at(2, 2), at(4, 2), at(5, 2), at(7, 2), at(8, 2),
+				at(2, 4), at(4, 4), at(5, 4), at(7, 4), at(8, 4),
+				at(2, 6), at(4, 6), at(5, 6), at(7, 6), at(8, 6),
+				at(2, 8), at(4, 8), at(5, 8), at(7, 8), at(8, 8)),
+		},
+		{
+			name: "xy-wing",
+			t:    XYWing,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(1, 1))
+				keep(gr, "13", at(1, 5))
+				keep(gr, "23", at(3, 2))
+			},
+			elim: cands("3", at(1, 2), at(1, 3), at(3, 4), at(3, 5), at(3, 6)),
+		},
+		{
+			name: "xyz-wing",
+			t:    XYZWing,
+			setup: func(gr *Grid) {
+				keep(gr, "123", at(1, 1))
+				keep(gr, "13", at(1, 5))
+				keep(gr, "23", at(2, 2))
+			},
+			elim: cands("3", at(1, 2), at(1, 3)),
+		},
+		{
+			name: "w-wing",
+			t:    WWing,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(1, 1), at(5, 9))
+				remove(gr, "1", row(9), at(9, 1), at(9, 9))
+			},
+			elim: cands("2", at(1, 9), at(5, 1)),
+		},
+	})
+}
+
+// TestColoringChainsUniqueness runs coloring, chain and unique rectangle
+// techniques on grids that hold only their pattern.
+func TestColoringChainsUniqueness(t *testing.T) {
+	// Digit 1 forms the chain r1c1=r7c1-r7c6=r2c6 of conjugate pairs in
+	// column 1, row 7 and column 6.
+	conjugates := func(gr *Grid) {
+		remove(gr, "1", col(1), at(1, 1), at(7, 1))
+		remove(gr, "1", row(7), at(7, 1), at(7, 6))
+		remove(gr, "1", col(6), at(7, 6), at(2, 6))
+	}
+	runTechniqueCases(t, []techniqueCase{
+		{
+			name:  "simple coloring",
+			t:     SimpleColoring,
+			setup: conjugates,
+			elim:  cands("1", at(1, 4), at(1, 5), at(2, 2), at(2, 3)),
+		},
+		{
+			name:  "x-chain",
+			t:     XChain,
+			setup: conjugates,
+			elim:  cands("1", at(1, 4), at(1, 5), at(2, 2), at(2, 3)),
+		},
+		{
+			name: "xy-chain",
+			t:    XYChain,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(1, 1))
+				keep(gr, "23", at(1, 5))
+				keep(gr, "13", at(5, 5))
+			},
+			elim: cands("1", at(5, 1)),
+		},
+		{
+			// 1r1c1=1r7c1-1r7c4=2r7c4-2r2c4=2r1c6 ends on a candidate
+			// sharing row 1 with the start.
+			name: "alternating inference chain",
+			t:    AlternatingChain,
+			setup: func(gr *Grid) {
+				remove(gr, "1", col(1), at(1, 1), at(7, 1))
+				keep(gr, "12", at(7, 4))
+				remove(gr, "2", box(2), at(2, 4), at(1, 6))
+			},
+			elim: append(cands("2", at(1, 1)), cands("1", at(1, 6))...),
+		},
+		{
+			name: "unique rectangle type 1",
+			t:    UniqueRectangle1,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(1, 1), at(1, 4), at(2, 1))
+				keep(gr, "125", at(2, 4))
+			},
+			elim: cands("12", at(2, 4)),
+		},
+		{
+			name: "unique rectangle type 2",
+			t:    UniqueRectangle2,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(1, 1), at(1, 4))
+				keep(gr, "125", at(2, 1), at(2, 4))
+			},
+			elim: cands("5", at(2, 2), at(2, 3), at(2, 5), at(2, 6), at(2, 7), at(2, 8), at(2, 9)),
+		},
+		{
+			name: "unique rectangle type 3",
+			t:    UniqueRectangle3,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(1, 1), at(1, 4))
+				keep(gr, "123", at(2, 1))
+				keep(gr, "124", at(2, 4))
+				keep(gr, "34", at(2, 7))
+			},
+			elim: cands("34", at(2, 2), at(2, 3), at(2, 5), at(2, 6), at(2, 8), at(2, 9)),
+		},
+		{
+			name: "unique rectangle type 4",
+			t:    UniqueRectangle4,
+			setup: func(gr *Grid) {
+				keep(gr, "12", at(1, 1), at(1, 4))
+				keep(gr, "125", at(2, 1))
+				keep(gr, "126", at(2, 4))
+				remove(gr, "1", row(2), at(2, 1), at(2, 4))
+			},
+			elim: cands("2", at(2, 1), at(2, 4)),
+		},
+	})
+}
+
+// TestBUGPlusOne empties four corners of a solved grid in two rows,
+// columns and boxes and leaves each with candidates 1 and 2, so every
+// candidate appears twice per unit; one corner also gets a 3, which
+// BUG+1 must place.
+func TestBUGPlusOne(t *testing.T) {
+	b := fixedPuzzle(t, Geometry9, Easy).Solution.Clone()
+	corners := []int{at(1, 1), at(1, 4), at(2, 1), at(2, 4)}
+	for _, i := range corners {
+		b[i] = 0
+	}
+	gr, err := NewGrid(Geometry9, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keep(gr, "12", corners...)
+	keep(gr, "123", at(1, 1))
+	step, ok := findBUG(gr)
+	if !ok {
+		t.Fatal("BUG+1 found nothing")
+	}
+	if want := cands("3", at(1, 1)); !sameCandidates(step.Placements, want) {
+		t.Errorf("placements %v, want %v", step.Placements, want)
+	}
+}
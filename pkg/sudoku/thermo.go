@@ -0,0 +1,285 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Thermometers is the rule of Thermo Sudoku: digits strictly increase
+// along each thermometer, from its bulb to its tip. Build it with
+// NewThermometers.
+type Thermometers struct {
+	paths   [][]int
+	spots   [][]thermoSpot
+	regions []Region
+}
+
+// thermoSpot is a cell's place on one thermometer.
+type thermoSpot struct {
+	thermo int
+	pos    int
+}
+
+// NewThermometers checks thermometers against a geometry. Each path lists
+// its cells from the bulb: two to Size cells on the board, each
+// orthogonally next to the one before and none visited twice. Separate
+// thermometers may share cells.
+func NewThermometers(g Geometry, paths [][]int) (*Thermometers, error) {
+	t := &Thermometers{paths: paths, spots: make([][]thermoSpot, g.Cells())}
+	for n, path := range paths {
+		if len(path) < 2 || len(path) > g.Size {
+			return nil, fmt.Errorf("%w: thermometer %d has %d cells", ErrInvalidRules, n+1, len(path))
+		}
+		for p, i := range path {
+			if i < 0 || i >= g.Cells() || containsInt(path[:p], i) {
+				return nil, fmt.Errorf("%w: thermometer %d reuses or misplaces cell %d", ErrInvalidRules, n+1, i)
+			}
+			if p > 0 && !containsInt(neighbours(g, path[p-1]), i) {
+				return nil, fmt.Errorf("%w: thermometer %d jumps to cell %d", ErrInvalidRules, n+1, i)
+			}
+			t.spots[i] = append(t.spots[i], thermoSpot{thermo: n, pos: p})
+		}
+		t.regions = append(t.regions, Region{
+			Unit:  Unit{Kind: ExtraUnit, Index: n, Name: fmt.Sprintf("thermometer %d", n+1)},
+			Cells: path,
+		})
+	}
+	return t, nil
+}
+
+// Paths returns the thermometers, bulb first. The slice is shared and
+// must not be modified.
+func (t *Thermometers) Paths() [][]int {
+	return t.paths
+}
+
+// Regions returns one region per thermometer, since increasing digits
+// never repeat.
+func (t *Thermometers) Regions(g Geometry) []Region {
+	return t.regions
+}
+
+// Allowed permits the digits that leave room for the cells before the
+// cell on each of its thermometers to climb to it, and for the cells
+// after it to climb on, given the digits already filled along the way.
+func (t *Thermometers) Allowed(g Geometry, b Board, cell int) uint16 {
+	mask := g.FullMask()
+	for _, s := range t.spots[cell] {
+		path := t.paths[s.thermo]
+		low, high := s.pos+1, g.Size-(len(path)-1-s.pos)
+		for q, i := range path {
+			if q == s.pos || b[i] == 0 {
+				continue
+			}
+			if q < s.pos {
+				low = max(low, int(b[i])+s.pos-q)
+			} else {
+				high = min(high, int(b[i])-(q-s.pos))
+			}
+		}
+		mask &= digitRange(low, high)
+	}
+	return mask
+}
+
+// narrow keeps, along each thermometer, the candidates above the smallest
+// one of the cell before and below the largest one of the cell after.
+func (t *Thermometers) narrow(g Geometry, b Board, cands []uint16) bool {
+	for _, path := range t.paths {
+		masks, ok := orderPath(g, b, cands, path)
+		if !ok {
+			return false
+		}
+		for p, i := range path {
+			if b[i] == 0 {
+				cands[i] &= masks[p]
+			}
+		}
+	}
+	return true
+}
+
+// orderPath returns the digits each cell of a thermometer may hold once
+// every cell climbs above the smallest digit the cell before it may hold
+// and stays below the largest the cell after it may hold. Filled cells
+// start from their digit, empty ones from cands. It reports false when a
+// cell is left with none.
+func orderPath(g Geometry, b Board, cands []uint16, path []int) ([]uint16, bool) {
+	masks := make([]uint16, len(path))
+	for p, i := range path {
+		masks[p] = cands[i]
+		if b[i] != 0 {
+			masks[p] = 1 << uint(b[i]-1)
+		}
+	}
+	for p := 1; p < len(path); p++ {
+		if masks[p-1] == 0 {
+			return nil, false
+		}
+		masks[p] &= digitRange(firstBit(masks[p-1])+1, g.Size)
+	}
+	for p := len(path) - 2; p >= 0; p-- {
+		if masks[p+1] == 0 {
+			return nil, false
+		}
+		masks[p] &= digitRange(1, lastBit(masks[p+1])-1)
+	}
+	return masks, masks[0] != 0
+}
+
+// digitRange returns the mask of the digits from low to high.
+func digitRange(low, high int) uint16 {
+	if low < 1 {
+		low = 1
+	}
+	if high < low {
+		return 0
+	}
+	return uint16(1<<uint(high)-1) &^ uint16(1<<uint(low-1)-1)
+}
+
+// lastBit returns the 1-based index of the highest set bit.
+func lastBit(mask uint16) int {
+	for i := 15; i >= 0; i-- {
+		if mask&(1<<uint(i)) != 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// findThermoOrder removes candidates that cannot climb a thermometer:
+// each cell must exceed some digit of the cell before it and stay below
+// some digit of the cell after it.
+func findThermoOrder(gr *Grid) (Step, bool) {
+	t := gr.lay.thermosOf()
+	if t == nil {
+		return Step{}, false
+	}
+	for n, path := range t.paths {
+		masks, ok := orderPath(gr.lay.geo, gr.values, gr.cands, path)
+		if !ok {
+			continue
+		}
+		var elims []Candidate
+		for p, i := range path {
+			if gr.values[i] != 0 {
+				continue
+			}
+			for _, d := range maskToValues(gr.cands[i]&^masks[p], gr.lay.geo.Size) {
+				elims = append(elims, Candidate{Cell: i, Digit: uint8(d)})
+			}
+		}
+		if len(elims) == 0 {
+			continue
+		}
+		return Step{
+			Technique:    ThermoOrder,
+			Units:        []Unit{t.regions[n].Unit},
+			Cells:        path,
+			Eliminations: elims,
+		}, true
+	}
+	return Step{}, false
+}
+
+// thermosOf returns the layout's thermometer rule, if it has one.
+func (l *layout) thermosOf() *Thermometers {
+	for _, c := range l.constraints {
+		if t, ok := c.(*Thermometers); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// thermoVariant is Thermo Sudoku: the classic rules plus thermometers
+// read off the solution.
+type thermoVariant struct{}
+
+// ThermoVariant generates Thermo Sudoku puzzles.
+var ThermoVariant = registerVariant(thermoVariant{})
+
+// maxThermoLength bounds generated thermometers.
+const maxThermoLength = 6
+
+// thermoMaxSize is the largest board Thermo puzzles are played on; on
+// bigger ones the search proving a puzzle unique takes too long.
+const thermoMaxSize = 10
+
+// thermoClueShare is the share of a classic puzzle's givens a Thermo
+// puzzle keeps, per difficulty; the thermometers carry the rest.
+var thermoClueShare = []float64{0.7, 0.6, 0.5, 0.45, 0.4, 0.4}
+
+// Name returns "thermo".
+func (thermoVariant) Name() string {
+	return "thermo"
+}
+
+// Base returns the standard rules on boards up to thermoMaxSize.
+func (thermoVariant) Base(g Geometry, r *rand.Rand) (*Rules, error) {
+	if g.Size > thermoMaxSize {
+		return nil, fmt.Errorf("%w: thermo is played on boards up to %dx%d, not %s", ErrInvalidGeometry, thermoMaxSize, thermoMaxSize, g)
+	}
+	return StandardRules(g)
+}
+
+// Decorate adds random thermometers over the solution.
+func (thermoVariant) Decorate(base *Rules, solution Board, r *rand.Rand) (*Rules, error) {
+	g := base.Geometry()
+	t, err := NewThermometers(g, randomThermos(r, g, solution))
+	if err != nil {
+		return nil, err
+	}
+	constraints := append(append([]Constraint(nil), base.Constraints()...), t)
+	return NewRules(g, constraints...)
+}
+
+// ClueTarget keeps a share of the classic target that shrinks with
+// difficulty.
+func (thermoVariant) ClueTarget(size int, d Difficulty) int {
+	if d < Easy || int(d) >= len(thermoClueShare) {
+		d = Hard
+	}
+	return int(float64(ClueTarget(size, d)) * thermoClueShare[d])
+}
+
+// randomThermos lays about one thermometer per digit over the solution.
+// Each starts at a random free cell and climbs to random free neighbours
+// holding larger digits; thermometers never share cells.
+func randomThermos(r *rand.Rand, g Geometry, solution Board) [][]int {
+	used := make([]bool, g.Cells())
+	limit := min(maxThermoLength, g.Size)
+	var paths [][]int
+	for _, start := range r.Perm(g.Cells()) {
+		if len(paths) == g.Size {
+			break
+		}
+		if used[start] {
+			continue
+		}
+		path := []int{start}
+		target := 2 + r.Intn(limit-1)
+		for len(path) < target {
+			last := path[len(path)-1]
+			var options []int
+			for _, n := range neighbours(g, last) {
+				if !used[n] && solution[n] > solution[last] {
+					options = append(options, n)
+				}
+			}
+			if len(options) == 0 {
+				break
+			}
+			path = append(path, options[r.Intn(len(options))])
+		}
+		if len(path) < 2 {
+			continue
+		}
+		for _, i := range path {
+			used[i] = true
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,133 @@
+package sudoku
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// TestNewThermometers checks which paths NewThermometers accepts.
+func TestNewThermometers(t *testing.T) {
+	cases := []struct {
+		name  string
+		paths [][]int
+		ok    bool
+	}{
+		{"row", [][]int{{0, 1, 2}}, true},
+		{"bend", [][]int{{0, 9, 10, 1}}, true},
+		{"shared cell", [][]int{{0, 1}, {1, 2}}, true},
+		{"one cell", [][]int{{0}}, false},
+		{"too long", [][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 17}}, false},
+		{"jump", [][]int{{0, 2}}, false},
+		{"diagonal step", [][]int{{0, 10}}, false},
+		{"revisit", [][]int{{0, 1, 0}}, false},
+		{"off board", [][]int{{80, 81}}, false},
+	}
+	for _, c := range cases {
+		_, err := NewThermometers(Geometry9, c.paths)
+		if c.ok && err != nil || !c.ok && !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("%s: error %v", c.name, err)
+		}
+	}
+}
+
+// TestThermoAllowed checks the digits a thermometer leaves a cell for its
+// place on the path and the digits filled along it.
+func TestThermoAllowed(t *testing.T) {
+	th, err := NewThermometers(Geometry9, [][]int{{0, 1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		filled map[int]uint8
+		cell   int
+		want   string
+	}{
+		{"bulb", nil, 0, "1234567"},
+		{"middle", nil, 1, "2345678"},
+		{"tip", nil, 2, "3456789"},
+		{"bulb under a 5", map[int]uint8{2: 5}, 0, "123"},
+		{"tip over a 4", map[int]uint8{0: 4}, 2, "6789"},
+		{"between 4 and 6", map[int]uint8{0: 4, 2: 6}, 1, "5"},
+		{"between 4 and 5", map[int]uint8{0: 4, 2: 5}, 1, ""},
+		{"off the path", nil, 40, "123456789"},
+	}
+	for _, c := range cases {
+		b := make(Board, Geometry9.Cells())
+		for i, v := range c.filled {
+			b[i] = v
+		}
+		if got, want := th.Allowed(Geometry9, b, c.cell), digitMask(c.want); got != want {
+			t.Errorf("%s: allowed %09b, want %09b", c.name, got, want)
+		}
+	}
+}
+
+// TestThermoNarrow checks that thermometers order the candidates along
+// each path and report paths that cannot climb.
+func TestThermoNarrow(t *testing.T) {
+	cases := []struct {
+		name   string
+		filled map[int]uint8
+		cands  map[int]string
+		want   map[int]string
+		ok     bool
+	}{
+		{"bulb of 5", nil, map[int]string{0: "5"}, map[int]string{0: "5", 1: "678", 2: "789"}, true},
+		{"tip of 3", nil, map[int]string{2: "3"}, map[int]string{0: "1", 1: "2", 2: "3"}, true},
+		{"filled middle", map[int]uint8{1: 5}, nil, map[int]string{0: "1234", 2: "6789"}, true},
+		{"bulb of 8", nil, map[int]string{0: "8"}, nil, false},
+	}
+	for _, c := range cases {
+		th, err := NewThermometers(Geometry9, [][]int{{0, 1, 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := make(Board, Geometry9.Cells())
+		for i, v := range c.filled {
+			b[i] = v
+		}
+		cands := make([]uint16, Geometry9.Cells())
+		for i := range cands {
+			cands[i] = Geometry9.FullMask()
+		}
+		for i, digits := range c.cands {
+			cands[i] = digitMask(digits)
+		}
+		ok := th.narrow(Geometry9, b, cands)
+		if ok != c.ok {
+			t.Errorf("%s: narrow reported %v", c.name, ok)
+			continue
+		}
+		for i, digits := range c.want {
+			if cands[i] != digitMask(digits) {
+				t.Errorf("%s: cell %d keeps %09b, want %s", c.name, i, cands[i], digits)
+			}
+		}
+	}
+}
+
+// TestThermoDecorate checks that generated thermometers climb through the
+// solution.
+func TestThermoDecorate(t *testing.T) {
+	solution := fixedPuzzle(t, Geometry9, Easy).Solution
+	r, err := ThermoVariant.Decorate(mustStandard(t, Geometry9), solution, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	th := r.lay.thermosOf()
+	if th == nil || len(th.Paths()) == 0 {
+		t.Fatal("no thermometers")
+	}
+	for _, path := range th.Paths() {
+		for p := 1; p < len(path); p++ {
+			if solution[path[p]] <= solution[path[p-1]] {
+				t.Errorf("thermometer %v does not climb through the solution", path)
+			}
+		}
+	}
+	if err := r.Check(solution); err != nil {
+		t.Errorf("solution breaks the thermometers: %v", err)
+	}
+}
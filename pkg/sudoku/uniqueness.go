@@ -0,0 +1,221 @@
+package sudoku
+
+// Uniqueness techniques assume the puzzle has exactly one solution, which
+// holds for every puzzle the generator produces.
+
+// rectangle is four empty corners r1c1, r1c2, r2c1, r2c2 sharing two
+// candidates a and b.
+type rectangle struct {
+	corners [4]int
+	ab      uint16
+}
+
+// deadlyRectangles lists rectangles where swapping a and b in all four
+// corners would keep every unit valid: each unit holds zero or two corners.
+func deadlyRectangles(gr *Grid) []rectangle {
+	g := gr.lay.geo
+	var rects []rectangle
+	for r1 := 0; r1 < g.Size; r1++ {
+		for r2 := r1 + 1; r2 < g.Size; r2++ {
+			for c1 := 0; c1 < g.Size; c1++ {
+				for c2 := c1 + 1; c2 < g.Size; c2++ {
+					corners := [4]int{g.Index(r1, c1), g.Index(r1, c2), g.Index(r2, c1), g.Index(r2, c2)}
+					common := g.FullMask()
+					for _, c := range corners {
+						common &= gr.cands[c]
+					}
+					if bitCount(common) < 2 || !pairsPerUnit(gr.lay, corners[:]) {
+						continue
+					}
+					digits := maskToValues(common, g.Size)
+					for i := 0; i < len(digits); i++ {
+						for j := i + 1; j < len(digits); j++ {
+							ab := uint16(1)<<uint(digits[i]-1) | uint16(1)<<uint(digits[j]-1)
+							rects = append(rects, rectangle{corners: corners, ab: ab})
+						}
+					}
+				}
+			}
+		}
+	}
+	return rects
+}
+
+// pairsPerUnit reports whether every unit holds zero or two of the cells.
+func pairsPerUnit(l *layout, cells []int) bool {
+	counts := map[int]int{}
+	for _, c := range cells {
+		for _, u := range l.cellUnits[c] {
+			counts[u]++
+		}
+	}
+	for _, n := range counts {
+		if n != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// findUniqueRectangle finds unique rectangles of the requested type.
+func findUniqueRectangle(gr *Grid, kind int) (Step, bool) {
+	t := [...]Technique{1: UniqueRectangle1, 2: UniqueRectangle2, 3: UniqueRectangle3, 4: UniqueRectangle4}[kind]
+	size := gr.lay.geo.Size
+	for _, rect := range deadlyRectangles(gr) {
+		var floor, roof []int
+		for _, c := range rect.corners {
+			if gr.cands[c] == rect.ab {
+				floor = append(floor, c)
+			} else {
+				roof = append(roof, c)
+			}
+		}
+		step := Step{Technique: t, Cells: rect.corners[:], Digits: maskDigits(rect.ab, size)}
+		switch {
+		case kind == 1 && len(floor) == 3:
+			for _, d := range maskDigits(rect.ab, size) {
+				step.Eliminations = append(step.Eliminations, Candidate{Cell: roof[0], Digit: d})
+			}
+			return step, true
+		case kind == 2 && len(floor) == 2:
+			extra := gr.cands[roof[0]] &^ rect.ab
+			if bitCount(extra) != 1 || gr.cands[roof[1]]&^rect.ab != extra {
+				continue
+			}
+			step.Digits = maskDigits(rect.ab|extra, size)
+			step.Eliminations = commonPeerElims(gr, uint8(firstBit(extra)), roof[0], roof[1])
+			if len(step.Eliminations) > 0 {
+				return step, true
+			}
+		case kind == 3 && len(floor) == 2:
+			if s, ok := uniqueRectangle3(gr, step, roof, rect.ab); ok {
+				return s, true
+			}
+		case kind == 4 && len(floor) == 2:
+			for _, u := range sharedUnits(gr.lay, roof) {
+				for _, x := range maskDigits(rect.ab, size) {
+					link := gr.digitCells(u, x)
+					if len(link) != 2 || !containsInt(link, roof[0]) || !containsInt(link, roof[1]) {
+						continue
+					}
+					y := uint8(firstBit(rect.ab &^ (1 << uint(x-1))))
+					step.Units = []Unit{gr.lay.units[u]}
+					step.Eliminations = []Candidate{{Cell: roof[0], Digit: y}, {Cell: roof[1], Digit: y}}
+					return step, true
+				}
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// uniqueRectangle3 treats the roof's extra candidates as one virtual cell
+// and looks for a naked subset with other cells of a unit the roof shares.
+func uniqueRectangle3(gr *Grid, step Step, roof []int, ab uint16) (Step, bool) {
+	size := gr.lay.geo.Size
+	extra := (gr.cands[roof[0]] | gr.cands[roof[1]]) &^ ab
+	for _, u := range sharedUnits(gr.lay, roof) {
+		var pool []int
+		for _, i := range gr.lay.unitCells[u] {
+			if gr.cands[i] != 0 && !containsInt(roof, i) {
+				pool = append(pool, i)
+			}
+		}
+		for n := 1; n <= 3 && n < len(pool); n++ {
+			var found Step
+			ok := combinations(len(pool), n, func(pick []int) bool {
+				union := extra
+				cells := make([]int, 0, n)
+				for _, p := range pick {
+					union |= gr.cands[pool[p]]
+					cells = append(cells, pool[p])
+				}
+				if bitCount(union) != n+1 || union&ab != 0 {
+					return false
+				}
+				var elims []Candidate
+				for _, i := range pool {
+					if containsInt(cells, i) {
+						continue
+					}
+					for _, d := range maskDigits(gr.cands[i]&union, size) {
+						elims = append(elims, Candidate{Cell: i, Digit: d})
+					}
+				}
+				if len(elims) == 0 {
+					return false
+				}
+				found = step
+				found.Units = []Unit{gr.lay.units[u]}
+				found.Cells = append(append([]int{}, step.Cells...), cells...)
+				found.Digits = maskDigits(ab|union, size)
+				found.Eliminations = elims
+				return true
+			})
+			if ok {
+				return found, true
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// sharedUnits returns every unit containing all the cells.
+func sharedUnits(l *layout, cells []int) []int {
+	var units []int
+	for _, u := range l.cellUnits[cells[0]] {
+		if unitContainsAll(l, u, cells) {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// findBUG finds a Bivalue Universal Grave plus one: every empty cell is
+// bivalue except one with three candidates. Without the digit that appears
+// three times in that cell's units, every candidate would appear exactly
+// twice per unit and the puzzle would have two solutions, so that digit
+// must be placed.
+func findBUG(gr *Grid) (Step, bool) {
+	size := gr.lay.geo.Size
+	extra := -1
+	for i, mask := range gr.cands {
+		switch count := bitCount(mask); {
+		case count == 0 || count == 2:
+		case count == 3 && extra < 0:
+			extra = i
+		default:
+			return Step{}, false
+		}
+	}
+	if extra < 0 {
+		return Step{}, false
+	}
+	for _, digit := range maskDigits(gr.cands[extra], size) {
+		trial := gr.Clone()
+		trial.cands[extra] &^= 1 << uint(digit-1)
+		if !bugState(trial) {
+			continue
+		}
+		return Step{
+			Technique:  BUGPlusOne,
+			Cells:      []int{extra},
+			Digits:     []uint8{digit},
+			Placements: []Candidate{{Cell: extra, Digit: digit}},
+		}, true
+	}
+	return Step{}, false
+}
+
+// bugState reports whether every candidate appears zero or two times in
+// every unit.
+func bugState(gr *Grid) bool {
+	for u := range gr.lay.units {
+		for d := 1; d <= gr.lay.geo.Size; d++ {
+			if n := len(gr.digitCells(u, uint8(d))); n != 0 && n != 2 {
+				return false
+			}
+		}
+	}
+	return true
+}
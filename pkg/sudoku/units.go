@@ -0,0 +1,216 @@
+package sudoku
+
+import (
+	"fmt"
+	"sync"
+)
+
+// UnitKind identifies the shape of a unit.
+type UnitKind int
+
+const (
+	RowUnit UnitKind = iota
+	ColumnUnit
+	BoxUnit
+	// ExtraUnit marks regions added by variant rules; Name labels them.
+	ExtraUnit
+)
+
+// Unit names a region of the board: a row, column, or box by index, or a
+// variant region by name.
+type Unit struct {
+	Kind  UnitKind
+	Index int
+	Name  string
+}
+
+// String returns a 1-based label such as "row 3" or "box 7", or the
+// unit's name when it has one.
+func (u Unit) String() string {
+	if u.Name != "" {
+		return u.Name
+	}
+	switch u.Kind {
+	case RowUnit:
+		return fmt.Sprintf("row %d", u.Index+1)
+	case ColumnUnit:
+		return fmt.Sprintf("column %d", u.Index+1)
+	case BoxUnit:
+		return fmt.Sprintf("box %d", u.Index+1)
+	default:
+		return fmt.Sprintf("region %d", u.Index+1)
+	}
+}
+
+// Units returns every row, column, and box of the geometry, in that order.
+func (g Geometry) Units() []Unit {
+	units := make([]Unit, 0, 3*g.Size)
+	for _, kind := range []UnitKind{RowUnit, ColumnUnit, BoxUnit} {
+		for i := 0; i < g.Size; i++ {
+			units = append(units, Unit{Kind: kind, Index: i})
+		}
+	}
+	return units
+}
+
+// UnitCells returns the flat indices of the cells in a unit.
+func (g Geometry) UnitCells(u Unit) []int {
+	cells := make([]int, 0, g.Size)
+	switch u.Kind {
+	case RowUnit:
+		for col := 0; col < g.Size; col++ {
+			cells = append(cells, g.Index(u.Index, col))
+		}
+	case ColumnUnit:
+		for row := 0; row < g.Size; row++ {
+			cells = append(cells, g.Index(row, u.Index))
+		}
+	case BoxUnit:
+		boxesPerRow := g.Size / g.BoxCols
+		boxRow := (u.Index / boxesPerRow) * g.BoxRows
+		boxCol := (u.Index % boxesPerRow) * g.BoxCols
+		for r := boxRow; r < boxRow+g.BoxRows; r++ {
+			for c := boxCol; c < boxCol+g.BoxCols; c++ {
+				cells = append(cells, g.Index(r, c))
+			}
+		}
+	}
+	return cells
+}
+
+// CellName returns a 1-based "r3c5" label for a flat index.
+func (g Geometry) CellName(i int) string {
+	return fmt.Sprintf("r%dc%d", i/g.Size+1, i%g.Size+1)
+}
+
+// layout caches the regions, houses, and peers of a set of rules, plus
+// its Dancing Links template once one is needed.
+// Houses (regions with one cell per digit) come first in regions, so a
+// house's index is the same in both tables.
+type layout struct {
+	geo         Geometry
+	constraints []Constraint
+	regions     []Region
+	cellRegions [][]int
+	units       []Unit
+	unitCells   [][]int
+	cellUnits   [][]int
+	unitIndex   map[Unit]int
+	peers       [][]int
+	pruners     []Constraint
+	narrowers   []narrower
+	classic     bool
+	exact       bool
+	dlxOnce     sync.Once
+	dlx         *dlxTemplate
+}
+
+// buildLayout precomputes region, house, and peer tables for a set of
+// constraints.
+func buildLayout(g Geometry, constraints []Constraint) *layout {
+	l := &layout{
+		geo:         g,
+		constraints: constraints,
+		cellRegions: make([][]int, g.Cells()),
+		cellUnits:   make([][]int, g.Cells()),
+		unitIndex:   map[Unit]int{},
+		peers:       make([][]int, g.Cells()),
+		classic:     isClassic(constraints),
+		exact:       true,
+	}
+	var extras []Region
+	for _, c := range constraints {
+		switch c.(type) {
+		case Houses, RegionSet:
+		default:
+			l.pruners = append(l.pruners, c)
+			l.exact = false
+			if n, ok := c.(narrower); ok {
+				l.narrowers = append(l.narrowers, n)
+			}
+		}
+		for _, r := range c.Regions(g) {
+			if len(r.Cells) == g.Size {
+				l.regions = append(l.regions, r)
+			} else {
+				extras = append(extras, r)
+				l.exact = false
+			}
+		}
+	}
+	for u, r := range l.regions {
+		l.units = append(l.units, r.Unit)
+		l.unitCells = append(l.unitCells, r.Cells)
+		l.unitIndex[r.Unit] = u
+		for _, i := range r.Cells {
+			l.cellUnits[i] = append(l.cellUnits[i], u)
+		}
+	}
+	l.regions = append(l.regions, extras...)
+	for r, region := range l.regions {
+		for _, i := range region.Cells {
+			l.cellRegions[i] = append(l.cellRegions[i], r)
+		}
+	}
+	for i := range l.peers {
+		seen := map[int]bool{i: true}
+		for _, r := range l.cellRegions[i] {
+			for _, j := range l.regions[r].Cells {
+				if !seen[j] {
+					seen[j] = true
+					l.peers[i] = append(l.peers[i], j)
+				}
+			}
+		}
+	}
+	return l
+}
+
+// isClassic reports whether constraints are exactly rows, columns, and
+// boxes.
+func isClassic(constraints []Constraint) bool {
+	seen := map[Constraint]bool{}
+	for _, c := range constraints {
+		h, ok := c.(Houses)
+		if !ok || h > Boxes {
+			return false
+		}
+		seen[c] = true
+	}
+	return len(seen) == 3
+}
+
+// sees reports whether two distinct cells share a region.
+func (l *layout) sees(a, b int) bool {
+	if a == b {
+		return false
+	}
+	for _, u := range l.cellRegions[a] {
+		for _, v := range l.cellRegions[b] {
+			if u == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unitOf returns the layout index of a house, or -1 when the rules have
+// no such house.
+func (l *layout) unitOf(kind UnitKind, index int) int {
+	u, ok := l.unitIndex[Unit{Kind: kind, Index: index}]
+	if !ok {
+		return -1
+	}
+	return u
+}
+
+// allowed returns the digits every non-region constraint permits in a
+// cell of b.
+func (l *layout) allowed(b Board, cell int) uint16 {
+	mask := l.geo.FullMask()
+	for _, c := range l.pruners {
+		mask &= c.Allowed(l.geo, b, cell)
+	}
+	return mask
+}
@@ -0,0 +1,95 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Variant is a family of puzzles with rules beyond the classic ones.
+// Generation fills a solution under the Base rules, then Decorate adds
+// the constraints read off that solution, such as cage sums, and the
+// givens are carved under the result.
+type Variant interface {
+	// Name is the variant's code in puzzle IDs, such as "killer".
+	Name() string
+	// Base returns the rules a solution is filled under. It fails for
+	// geometries the variant does not support.
+	Base(g Geometry, r *rand.Rand) (*Rules, error)
+	// Decorate returns the rules a puzzle with this solution is carved
+	// under.
+	Decorate(base *Rules, solution Board, r *rand.Rand) (*Rules, error)
+	// ClueTarget returns the number of givens to aim for.
+	ClueTarget(size int, d Difficulty) int
+}
+
+// variants indexes the built-in variants by name.
+var variants = map[string]Variant{}
+
+// registerVariant adds a built-in variant to the catalog.
+func registerVariant(v Variant) Variant {
+	variants[v.Name()] = v
+	return v
+}
+
+// VariantByName returns the built-in variant with a name.
+func VariantByName(name string) (Variant, error) {
+	v, ok := variants[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownVariant, name)
+	}
+	return v, nil
+}
+
+// VariantNames lists the built-in variants in alphabetical order.
+func VariantNames() []string {
+	names := make([]string, 0, len(variants))
+	for name := range variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fixedVariant is a variant whose extra constraints do not depend on the
+// solution, such as X-Sudoku's diagonals. Its rules are built once per
+// geometry. A positive maxSize limits the boards it supports.
+type fixedVariant struct {
+	name    string
+	extra   func(g Geometry) []Constraint
+	maxSize int
+	rules   sync.Map
+}
+
+// Name returns the variant's name.
+func (v *fixedVariant) Name() string {
+	return v.name
+}
+
+// Base returns the standard constraints plus the variant's own, cached by
+// geometry.
+func (v *fixedVariant) Base(g Geometry, r *rand.Rand) (*Rules, error) {
+	if v.maxSize > 0 && g.Size > v.maxSize {
+		return nil, fmt.Errorf("%w: %s is played on boards up to %dx%d, not %s", ErrInvalidGeometry, v.name, v.maxSize, v.maxSize, g)
+	}
+	if rules, ok := v.rules.Load(g); ok {
+		return rules.(*Rules), nil
+	}
+	rules, err := NewRules(g, append(StandardConstraints(), v.extra(g)...)...)
+	if err != nil {
+		return nil, err
+	}
+	stored, _ := v.rules.LoadOrStore(g, rules)
+	return stored.(*Rules), nil
+}
+
+// Decorate returns the base rules unchanged.
+func (v *fixedVariant) Decorate(base *Rules, solution Board, r *rand.Rand) (*Rules, error) {
+	return base, nil
+}
+
+// ClueTarget uses the classic targets.
+func (v *fixedVariant) ClueTarget(size int, d Difficulty) int {
+	return ClueTarget(size, d)
+}
@@ -0,0 +1,40 @@
+package sudoku
+
+import "fmt"
+
+// Windows returns the extra houses of Windoku: box-shaped windows set one
+// cell in from the top-left corner with one cell between neighbours, as
+// many as fit. A 9x9 board gets the classic four 3x3 windows.
+func Windows(g Geometry) RegionSet {
+	var windows RegionSet
+	for top := 1; top+g.BoxRows <= g.Size; top += g.BoxRows + 1 {
+		for left := 1; left+g.BoxCols <= g.Size; left += g.BoxCols + 1 {
+			cells := make([]int, 0, g.Size)
+			for row := top; row < top+g.BoxRows; row++ {
+				for col := left; col < left+g.BoxCols; col++ {
+					cells = append(cells, g.Index(row, col))
+				}
+			}
+			n := len(windows)
+			windows = append(windows, Region{
+				Unit:  Unit{Kind: ExtraUnit, Index: n, Name: fmt.Sprintf("window %d", n+1)},
+				Cells: cells,
+			})
+		}
+	}
+	return windows
+}
+
+// windokuMaxSize is the largest Windoku board; beyond it, filling a grid
+// around the windows takes the search too long.
+const windokuMaxSize = 10
+
+// WindokuVariant generates Windoku puzzles, where the Windows also hold
+// every digit once, on boards up to windokuMaxSize.
+var WindokuVariant = registerVariant(&fixedVariant{
+	name: "windoku",
+	extra: func(g Geometry) []Constraint {
+		return []Constraint{Windows(g)}
+	},
+	maxSize: windokuMaxSize,
+})
@@ -0,0 +1,56 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestWindows checks how many windows fit each board and where the first
+// one sits.
+func TestWindows(t *testing.T) {
+	cases := []struct {
+		g     Geometry
+		count int
+		first []int
+	}{
+		{Geometry6, 2, []int{7, 8, 9, 13, 14, 15}},
+		{Geometry9, 4, []int{10, 11, 12, 19, 20, 21, 28, 29, 30}},
+		{Geometry10, 3, []int{11, 12, 13, 14, 15, 21, 22, 23, 24, 25}},
+	}
+	for _, c := range cases {
+		w := Windows(c.g)
+		if len(w) != c.count || !slices.Equal(w[0].Cells, c.first) {
+			t.Errorf("%s: %d windows, first %v", c.g, len(w), w[0].Cells)
+		}
+	}
+}
+
+// TestWindokuVariant checks that Windoku stops past its largest board and
+// that its puzzles fill every window with each digit once.
+func TestWindokuVariant(t *testing.T) {
+	if _, err := WindokuVariant.Base(Geometry12, nil); !errors.Is(err, ErrInvalidGeometry) {
+		t.Errorf("12x12 Windoku: error %v, want ErrInvalidGeometry", err)
+	}
+	r, err := WindokuVariant.Base(Geometry9, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Regions()) != 31 {
+		t.Errorf("9x9 Windoku has %d regions, want 31", len(r.Regions()))
+	}
+	p, err := GenerateWith(context.Background(), Geometry9, Easy, Options{Seed: 1, Variant: WindokuVariant})
+	if err != nil && !errors.Is(err, ErrWrongDifficulty) {
+		t.Fatal(err)
+	}
+	for _, w := range Windows(Geometry9) {
+		seen := uint16(0)
+		for _, i := range w.Cells {
+			seen |= 1 << uint(p.Solution[i]-1)
+		}
+		if seen != Geometry9.FullMask() {
+			t.Errorf("%s repeats a digit in %v", w.Unit, p.Solution)
+		}
+	}
+}
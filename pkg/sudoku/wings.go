@@ -0,0 +1,138 @@
+package sudoku
+
+// findXYWing finds a bivalue pivot {x,y} seeing pincers {x,z} and {y,z}.
+// Whichever value the pivot takes, one pincer is z, so z is removed from
+// cells that see both pincers.
+func findXYWing(gr *Grid) (Step, bool) {
+	size := gr.lay.geo.Size
+	for pivot, pm := range gr.cands {
+		if bitCount(pm) != 2 {
+			continue
+		}
+		for _, a := range gr.lay.peers[pivot] {
+			am := gr.cands[a]
+			if bitCount(am) != 2 || bitCount(am&pm) != 1 {
+				continue
+			}
+			z := am &^ pm
+			for _, b := range gr.lay.peers[pivot] {
+				if b == a || gr.cands[b] != (pm&^am)|z {
+					continue
+				}
+				digit := uint8(firstBit(z))
+				elims := commonPeerElims(gr, digit, a, b)
+				if len(elims) == 0 {
+					continue
+				}
+				return Step{
+					Technique:    XYWing,
+					Cells:        []int{pivot, a, b},
+					Digits:       maskDigits(pm|z, size),
+					Eliminations: elims,
+				}, true
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// findXYZWing finds a trivalue pivot {x,y,z} seeing pincers {x,z} and
+// {y,z}. One of the three cells must be z, so z is removed from cells
+// that see all three.
+func findXYZWing(gr *Grid) (Step, bool) {
+	size := gr.lay.geo.Size
+	for pivot, pm := range gr.cands {
+		if bitCount(pm) != 3 {
+			continue
+		}
+		for _, a := range gr.lay.peers[pivot] {
+			am := gr.cands[a]
+			if bitCount(am) != 2 || am&^pm != 0 {
+				continue
+			}
+			for _, b := range gr.lay.peers[pivot] {
+				bm := gr.cands[b]
+				if b <= a || bitCount(bm) != 2 || bm&^pm != 0 || am|bm != pm {
+					continue
+				}
+				digit := uint8(firstBit(am & bm))
+				elims := commonPeerElims(gr, digit, pivot, a, b)
+				if len(elims) == 0 {
+					continue
+				}
+				return Step{
+					Technique:    XYZWing,
+					Cells:        []int{pivot, a, b},
+					Digits:       maskDigits(pm, size),
+					Eliminations: elims,
+				}, true
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// findWWing finds two identical bivalue cells {x,y} joined by a strong
+// link on x: a unit where x fits only two cells, one seeing each wing.
+// One wing must be y, so y is removed from cells that see both wings.
+func findWWing(gr *Grid) (Step, bool) {
+	size := gr.lay.geo.Size
+	for c1, mask := range gr.cands {
+		if bitCount(mask) != 2 {
+			continue
+		}
+		for c2 := c1 + 1; c2 < len(gr.cands); c2++ {
+			if gr.cands[c2] != mask || gr.lay.sees(c1, c2) {
+				continue
+			}
+			for _, x := range maskDigits(mask, size) {
+				y := uint8(firstBit(mask &^ (1 << uint(x-1))))
+				elims := commonPeerElims(gr, y, c1, c2)
+				if len(elims) == 0 {
+					continue
+				}
+				for u := range gr.lay.units {
+					link := gr.digitCells(u, x)
+					if len(link) != 2 || containsInt(link, c1) || containsInt(link, c2) {
+						continue
+					}
+					a, b := link[0], link[1]
+					if !(gr.lay.sees(a, c1) && gr.lay.sees(b, c2)) && !(gr.lay.sees(a, c2) && gr.lay.sees(b, c1)) {
+						continue
+					}
+					return Step{
+						Technique:    WWing,
+						Units:        []Unit{gr.lay.units[u]},
+						Cells:        []int{c1, c2, a, b},
+						Digits:       []uint8{x, y},
+						Eliminations: elims,
+					}, true
+				}
+			}
+		}
+	}
+	return Step{}, false
+}
+
+// commonPeerElims lists eliminations of a digit from cells that see every
+// cell in the pattern.
+func commonPeerElims(gr *Grid, digit uint8, pattern ...int) []Candidate {
+	mask := uint16(1) << uint(digit-1)
+	var elims []Candidate
+	for _, i := range gr.lay.peers[pattern[0]] {
+		if gr.cands[i]&mask == 0 || containsInt(pattern, i) {
+			continue
+		}
+		seesAll := true
+		for _, p := range pattern[1:] {
+			if !gr.lay.sees(i, p) {
+				seesAll = false
+				break
+			}
+		}
+		if seesAll {
+			elims = append(elims, Candidate{Cell: i, Digit: digit})
+		}
+	}
+	return elims
+}